@@ -0,0 +1,83 @@
+package balert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lamber92/go-brick/berror/bcode"
+)
+
+// Source where the alerted error comes from
+type Source string
+
+const (
+	SourceLog     Source = "log"
+	SourcePanic   Source = "panic"
+	SourceManual  Source = "manual"
+	SourceUnknown Source = "unknown"
+)
+
+// Sink deliver the alerts, such as the webhooks of the chat tools
+type Sink interface {
+	// Name the name of the sink, used in the logs of the failed deliveries
+	Name() string
+	// Send deliver the alert, it is called in a goroutine of the notifier.
+	Send(ctx context.Context, alert *Alert) error
+}
+
+// Alert an error at or above the threshold level, or the digest of the suppressed ones
+type Alert struct {
+	Service     string      `json:"service,omitempty"`
+	Source      Source      `json:"source"`
+	Fingerprint string      `json:"fingerprint"`
+	Code        int         `json:"code"`
+	Level       bcode.Level `json:"-"`
+	LevelName   string      `json:"level"`
+	Reason      string      `json:"reason"`
+	// Message the message given to the logger
+	Message string `json:"message,omitempty"`
+	// Error the error whose details are redacted (see berror.RedactError)
+	Error   string    `json:"error"`
+	TraceID string    `json:"trace_id,omitempty"`
+	Time    time.Time `json:"time"`
+
+	// Digest the alert summarizes the occurrences suppressed by throttling, which are counted by Suppressed
+	Digest     bool      `json:"digest,omitempty"`
+	Suppressed uint64    `json:"suppressed,omitempty"`
+	FirstSeen  time.Time `json:"first_seen,omitempty"`
+	LastSeen   time.Time `json:"last_seen,omitempty"`
+}
+
+// Title the first line of the text
+func (a *Alert) Title() string {
+	service := ""
+	if len(a.Service) > 0 {
+		service = "[" + a.Service + "] "
+	}
+	if a.Digest {
+		return fmt.Sprintf("%s[%s] %d more occurrences of: %s", service, strings.ToUpper(a.LevelName), a.Suppressed, a.Reason)
+	}
+	return fmt.Sprintf("%s[%s] %s", service, strings.ToUpper(a.LevelName), a.Reason)
+}
+
+// Text render the alert as plain text, for the chat tools
+func (a *Alert) Text() string {
+	b := &strings.Builder{}
+	b.WriteString(a.Title())
+	if len(a.Message) > 0 {
+		b.WriteString("\nmessage: " + a.Message)
+	}
+	_, _ = fmt.Fprintf(b, "\ncode: %d\nsource: %s\nfingerprint: %s", a.Code, a.Source, a.Fingerprint)
+	if len(a.TraceID) > 0 {
+		b.WriteString("\ntrace_id: " + a.TraceID)
+	}
+	if a.Digest {
+		_, _ = fmt.Fprintf(b, "\nsuppressed: %d between %s and %s",
+			a.Suppressed, a.FirstSeen.Format(time.RFC3339), a.LastSeen.Format(time.RFC3339))
+	}
+	b.WriteString("\nerror: " + a.Error)
+	b.WriteString("\ntime: " + a.Time.Format(time.RFC3339))
+	return b.String()
+}
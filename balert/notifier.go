@@ -0,0 +1,278 @@
+package balert
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/blog"
+	"github.com/lamber92/go-brick/blog/logger"
+	"github.com/lamber92/go-brick/bpanic"
+	"github.com/lamber92/go-brick/btrace"
+)
+
+const (
+	defaultThreshold = bcode.LvCritical
+	defaultThrottle  = 5 * time.Minute
+	defaultTimeout   = 5 * time.Second
+	defaultQueueSize = 256
+
+	recoverMessage = "panic recovered"
+)
+
+// Option the options of New
+type Option func(n *Notifier)
+
+// WithThreshold the errors whose levels (see bcode.GetLevel) are at or above the threshold are alerted, bcode.LvCritical by default
+func WithThreshold(level bcode.Level) Option {
+	return func(n *Notifier) {
+		n.threshold = level
+	}
+}
+
+// WithSinks the sinks the alerts are sent to
+func WithSinks(sinks ...Sink) Option {
+	return func(n *Notifier) {
+		n.sinks = append(n.sinks, sinks...)
+	}
+}
+
+// WithThrottle alert a fingerprint (see berror.Fingerprint) at most once in the window,
+// the occurrences in the window are suppressed and summarized by a digest when it ends.
+// defaults to 5 minutes, a window <= 0 disables the throttling.
+func WithThrottle(window time.Duration) Option {
+	return func(n *Notifier) {
+		n.throttle = window
+	}
+}
+
+// WithTimeout the timeout of sending an alert to a sink, 5 seconds by default
+func WithTimeout(timeout time.Duration) Option {
+	return func(n *Notifier) {
+		if timeout > 0 {
+			n.timeout = timeout
+		}
+	}
+}
+
+// WithQueueSize the capacity of the queue of the alerts waiting to be sent, 256 by default.
+// the alerts are dropped when it is full.
+func WithQueueSize(size int) Option {
+	return func(n *Notifier) {
+		if size > 0 {
+			n.queueSize = size
+		}
+	}
+}
+
+// WithService the name of the service carried by the alerts
+func WithService(name string) Option {
+	return func(n *Notifier) {
+		n.service = name
+	}
+}
+
+// Notifier send the errors at or above the threshold level to the sinks, throttled by fingerprint.
+// the alerts are queued and sent one by one by a background goroutine in order,
+// so that the callers are not blocked and a digest never overtakes the alert it summarizes.
+type Notifier struct {
+	service   string
+	threshold bcode.Level
+	throttle  time.Duration
+	timeout   time.Duration
+	queueSize int
+	sinks     []Sink
+
+	windows map[string]*throttleWindow
+	queue   chan *Alert
+	done    chan struct{}
+	closed  bool
+	mu      sync.Mutex
+}
+
+// throttleWindow the occurrences of a fingerprint since it was alerted
+type throttleWindow struct {
+	first      *Alert
+	last       *Alert
+	suppressed uint64
+	timer      *time.Timer
+}
+
+// New create a notifier
+func New(opts ...Option) *Notifier {
+	n := &Notifier{
+		threshold: defaultThreshold,
+		throttle:  defaultThrottle,
+		timeout:   defaultTimeout,
+		queueSize: defaultQueueSize,
+		windows:   make(map[string]*throttleWindow),
+		done:      make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(n)
+	}
+	n.queue = make(chan *Alert, n.queueSize)
+	go n.work()
+	return n
+}
+
+// Install register the hooks of n into blog (see blog.RegisterErrorHook) and bpanic (see bpanic.RegisterRecoverHook),
+// so that the errors logged at the error level and the recovered panics are alerted.
+// nb. this function is not thread-safe, call it when you initialize the program.
+func Install(n *Notifier) {
+	blog.RegisterErrorHook(n.LogHook())
+	bpanic.RegisterRecoverHook(n.RecoverHook())
+}
+
+// LogHook the hook of blog, see blog.RegisterErrorHook
+func (n *Notifier) LogHook() blog.ErrorHook {
+	return func(ctx context.Context, err error, msg string) {
+		n.notify(ctx, SourceLog, err, msg)
+	}
+}
+
+// RecoverHook the hook of bpanic, see bpanic.RegisterRecoverHook
+func (n *Notifier) RecoverHook() func(err error) {
+	return func(err error) {
+		n.notify(context.Background(), SourcePanic, err, recoverMessage)
+	}
+}
+
+// Notify alert err if its level is at or above the threshold.
+// returns 'true' if the alert is sent, 'false' if it is under the threshold, throttled or n is closed.
+func (n *Notifier) Notify(ctx context.Context, err error, msg string) bool {
+	return n.notify(ctx, SourceManual, err, msg)
+}
+
+func (n *Notifier) notify(ctx context.Context, source Source, err error, msg string) bool {
+	if err == nil {
+		return false
+	}
+	code := berror.CodeOf(err)
+	level := bcode.GetLevel(code)
+	if level < n.threshold {
+		return false
+	}
+	alert := &Alert{
+		Service:     n.service,
+		Source:      source,
+		Fingerprint: berror.Fingerprint(err),
+		Code:        code.ToInt(),
+		Level:       level,
+		LevelName:   level.String(),
+		Reason:      fmt.Sprintf("%s", err),
+		Message:     msg,
+		Error:       berror.RedactError(err),
+		Time:        time.Now(),
+	}
+	if ctx != nil {
+		alert.TraceID = btrace.GetTraceID(ctx)
+	}
+	return n.admit(alert)
+}
+
+// admit queue the alert unless it is throttled, the suppressed ones are counted into the window of the fingerprint
+func (n *Notifier) admit(alert *Alert) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return false
+	}
+	if n.throttle > 0 {
+		if w, ok := n.windows[alert.Fingerprint]; ok {
+			w.suppressed++
+			w.last = alert
+			return false
+		}
+		w := &throttleWindow{first: alert, last: alert}
+		w.timer = time.AfterFunc(n.throttle, func() {
+			n.endWindow(alert.Fingerprint, w)
+		})
+		n.windows[alert.Fingerprint] = w
+	}
+	return n.enqueue(alert)
+}
+
+// enqueue nb. it must be called with the lock held, so that the queue is not closed meanwhile
+func (n *Notifier) enqueue(alert *Alert) bool {
+	select {
+	case n.queue <- alert:
+		return true
+	default:
+		logger.Infra.Warnw("alert queue is full, drop the alert", logger.NewField().
+			String("fingerprint", alert.Fingerprint).
+			Bool("digest", alert.Digest))
+		return false
+	}
+}
+
+// endWindow send the digest if there are suppressed occurrences in the window
+func (n *Notifier) endWindow(fingerprint string, w *throttleWindow) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	// it has been ended by Close
+	if n.windows[fingerprint] != w {
+		return
+	}
+	delete(n.windows, fingerprint)
+	if w.suppressed == 0 {
+		return
+	}
+	n.enqueue(w.digest())
+}
+
+func (w *throttleWindow) digest() *Alert {
+	out := *w.last
+	out.Digest = true
+	out.Suppressed = w.suppressed
+	out.FirstSeen = w.first.Time
+	out.LastSeen = w.last.Time
+	out.Time = time.Now()
+	return &out
+}
+
+// work send the queued alerts in order until the queue is closed
+func (n *Notifier) work() {
+	defer close(n.done)
+	for alert := range n.queue {
+		n.send(alert)
+	}
+}
+
+func (n *Notifier) send(alert *Alert) {
+	for _, s := range n.sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+		if err := s.Send(ctx, alert); err != nil {
+			logger.Infra.WithError(err).Warnw("send alert fail", logger.NewField().
+				String("sink", s.Name()).
+				String("fingerprint", alert.Fingerprint))
+		}
+		cancel()
+	}
+}
+
+// Close end the throttling windows, send their digests, and wait for the queued alerts being sent.
+// the following errors are not alerted.
+func (n *Notifier) Close() error {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		<-n.done
+		return nil
+	}
+	n.closed = true
+	for fp, w := range n.windows {
+		w.timer.Stop()
+		delete(n.windows, fp)
+		if w.suppressed > 0 {
+			n.enqueue(w.digest())
+		}
+	}
+	close(n.queue)
+	n.mu.Unlock()
+	<-n.done
+	return nil
+}
@@ -0,0 +1,207 @@
+package balert_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lamber92/go-brick/balert"
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/lamber92/go-brick/blog"
+	"github.com/lamber92/go-brick/bpanic"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordSink struct {
+	ch chan *balert.Alert
+}
+
+func newRecordSink() *recordSink {
+	return &recordSink{ch: make(chan *balert.Alert, 16)}
+}
+
+func (s *recordSink) Name() string {
+	return "record"
+}
+
+func (s *recordSink) Send(ctx context.Context, alert *balert.Alert) error {
+	s.ch <- alert
+	return nil
+}
+
+func (s *recordSink) next(t *testing.T) *balert.Alert {
+	select {
+	case a := <-s.ch:
+		return a
+	case <-time.After(time.Second):
+		t.Fatal("no alert is sent")
+	}
+	return nil
+}
+
+func (s *recordSink) none(t *testing.T, wait time.Duration) {
+	select {
+	case a := <-s.ch:
+		t.Fatalf("unexpected alert: %s", a.Title())
+	case <-time.After(wait):
+	}
+}
+
+// newError the errors created here share a fingerprint
+func newError(reason string) error {
+	return berror.NewInternalError(nil, reason, map[string]string{"token": "abc"})
+}
+
+func TestNotifier_Threshold(t *testing.T) {
+	sink := newRecordSink()
+	n := balert.New(balert.WithSinks(sink), balert.WithService("order"))
+	defer n.Close()
+
+	assert.False(t, n.Notify(context.Background(), nil, "nil"))
+	assert.False(t, n.Notify(context.Background(), berror.NewNotFound(nil, "no such order"), "notice"))
+	assert.True(t, n.Notify(context.Background(), newError("db down"), "query order fail"))
+
+	a := sink.next(t)
+	assert.Equal(t, "order", a.Service)
+	assert.Equal(t, balert.SourceManual, a.Source)
+	assert.Equal(t, bcode.InternalError.ToInt(), a.Code)
+	assert.Equal(t, bcode.LvCritical, a.Level)
+	assert.Equal(t, "critical", a.LevelName)
+	assert.Equal(t, "db down", a.Reason)
+	assert.Equal(t, "query order fail", a.Message)
+	assert.NotContains(t, a.Error, "abc")
+	assert.Equal(t, "[order] [CRITICAL] db down", a.Title())
+	sink.none(t, 50*time.Millisecond)
+
+	lower := balert.New(balert.WithSinks(sink), balert.WithThreshold(bcode.LvWarning))
+	defer lower.Close()
+	assert.True(t, lower.Notify(context.Background(), berror.NewRequestTimeout(nil, "slow"), "warning"))
+	assert.Equal(t, "warning", sink.next(t).LevelName)
+}
+
+func TestNotifier_Throttle(t *testing.T) {
+	sink := newRecordSink()
+	n := balert.New(balert.WithSinks(sink), balert.WithThrottle(100*time.Millisecond))
+	defer n.Close()
+
+	ctx := context.Background()
+	assert.True(t, n.Notify(ctx, newError("db down"), "first"))
+	assert.False(t, n.Notify(ctx, newError("db down"), "second"))
+	assert.False(t, n.Notify(ctx, newError("db down"), "third"))
+	first := sink.next(t)
+	assert.False(t, first.Digest)
+	assert.Equal(t, "first", first.Message)
+
+	// the digest is sent when the window ends
+	digest := sink.next(t)
+	assert.True(t, digest.Digest)
+	assert.Equal(t, uint64(2), digest.Suppressed)
+	assert.Equal(t, "third", digest.Message)
+	assert.Equal(t, first.Fingerprint, digest.Fingerprint)
+	assert.Equal(t, first.Time, digest.FirstSeen)
+	assert.Contains(t, digest.Title(), "2 more occurrences of: db down")
+
+	// a new window starts after the suppression ends
+	assert.True(t, n.Notify(ctx, newError("db down"), "again"))
+	assert.Equal(t, "again", sink.next(t).Message)
+	// no digest for the window without suppression
+	sink.none(t, 200*time.Millisecond)
+
+	// another fingerprint is throttled separately
+	assert.True(t, n.Notify(ctx, berror.New(bstatus.New(bcode.ServiceUnavailable, "mq down", nil)), "mq"))
+	assert.Equal(t, "mq down", sink.next(t).Reason)
+}
+
+func TestNotifier_PlainErrors(t *testing.T) {
+	sink := newRecordSink()
+	n := balert.New(balert.WithSinks(sink), balert.WithThrottle(time.Hour))
+	defer n.Close()
+
+	// the plain errors are Unknown, the unrelated ones are not throttled together
+	ctx := context.Background()
+	assert.True(t, n.Notify(ctx, errors.New("db down"), "query"))
+	assert.True(t, n.Notify(ctx, errors.New("disk full"), "write"))
+	assert.False(t, n.Notify(ctx, errors.New("db down"), "query"))
+	first, second := sink.next(t), sink.next(t)
+	assert.Equal(t, "db down", first.Reason)
+	assert.Equal(t, "disk full", second.Reason)
+	assert.NotEqual(t, first.Fingerprint, second.Fingerprint)
+}
+
+func TestNotifier_Close(t *testing.T) {
+	sink := newRecordSink()
+	n := balert.New(balert.WithSinks(sink), balert.WithThrottle(time.Hour))
+
+	ctx := context.Background()
+	assert.True(t, n.Notify(ctx, newError("db down"), "first"))
+	assert.False(t, n.Notify(ctx, newError("db down"), "second"))
+	assert.NoError(t, n.Close())
+
+	// the alerts are sent before Close returns, the digest of the open window included
+	assert.Len(t, sink.ch, 2)
+	assert.False(t, sink.next(t).Digest)
+	assert.Equal(t, uint64(1), sink.next(t).Suppressed)
+	assert.False(t, n.Notify(ctx, newError("db down"), "closed"))
+}
+
+func TestInstall(t *testing.T) {
+	sink := newRecordSink()
+	n := balert.New(balert.WithSinks(sink), balert.WithThrottle(0))
+	defer n.Close()
+	balert.Install(n)
+
+	ctx := context.Background()
+	blog.Warn(ctx, newError("warn is not hooked"), "warn")
+	blog.Error(ctx, berror.NewNotFound(nil, "under threshold"), "not found")
+	sink.none(t, 50*time.Millisecond)
+
+	blog.Errorf(ctx, newError("db down"), "query %s fail", "order")
+	a := sink.next(t)
+	assert.Equal(t, balert.SourceLog, a.Source)
+	assert.Equal(t, "query order fail", a.Message)
+
+	func() {
+		defer bpanic.Recover(func(err error) {})
+		panic("boom")
+	}()
+	a = sink.next(t)
+	assert.Equal(t, balert.SourcePanic, a.Source)
+	assert.Equal(t, bcode.InternalError.ToInt(), a.Code)
+}
+
+type blockSink struct {
+	release chan struct{}
+	sent    chan *balert.Alert
+}
+
+func (s *blockSink) Name() string {
+	return "block"
+}
+
+func (s *blockSink) Send(ctx context.Context, alert *balert.Alert) error {
+	<-s.release
+	s.sent <- alert
+	return nil
+}
+
+func TestNotifier_QueueFull(t *testing.T) {
+	sink := &blockSink{release: make(chan struct{}), sent: make(chan *balert.Alert, 4)}
+	n := balert.New(balert.WithSinks(sink), balert.WithThrottle(0), balert.WithQueueSize(1))
+
+	ctx := context.Background()
+	assert.True(t, n.Notify(ctx, newError("db down"), "taken by the worker"))
+	// wait for the worker to block in the sink, so that the queue is empty
+	assert.Eventually(t, func() bool {
+		return n.Notify(ctx, newError("db down"), "queued")
+	}, time.Second, 10*time.Millisecond)
+	assert.False(t, n.Notify(ctx, newError("db down"), "dropped"))
+
+	close(sink.release)
+	assert.NoError(t, n.Close())
+	assert.Len(t, sink.sent, 2)
+	assert.Equal(t, "taken by the worker", (<-sink.sent).Message)
+	assert.Equal(t, "queued", (<-sink.sent).Message)
+}
@@ -0,0 +1,131 @@
+package balert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/internal/json"
+)
+
+const maxResponseBody = 4 << 10
+
+// WebhookConfig webhook sink options
+type WebhookConfig struct {
+	// URL the url of the webhook
+	URL string
+	// Headers extra http headers, such as authorization
+	Headers map[string]string
+	// Client the http client, http.DefaultClient if nil
+	Client *http.Client
+}
+
+// WebhookSink Sink impl posting the alerts as JSON to a webhook
+type WebhookSink struct {
+	name   string
+	conf   WebhookConfig
+	encode func(alert *Alert) any
+	// check the body of a 2xx response, some chat tools report the failures in it
+	check func(body []byte) error
+}
+
+// NewJSONSink create a sink posting the Alert as it is
+func NewJSONSink(conf WebhookConfig) *WebhookSink {
+	return newWebhookSink("json", conf, func(alert *Alert) any { return alert }, nil)
+}
+
+// NewSlackSink create a sink posting to a Slack-compatible incoming webhook
+func NewSlackSink(conf WebhookConfig) *WebhookSink {
+	return newWebhookSink("slack", conf, func(alert *Alert) any {
+		return map[string]any{"text": alert.Text()}
+	}, nil)
+}
+
+// NewFeishuSink create a sink posting text messages to a Feishu (Lark) custom bot
+func NewFeishuSink(conf WebhookConfig) *WebhookSink {
+	return newWebhookSink("feishu", conf, func(alert *Alert) any {
+		return map[string]any{
+			"msg_type": "text",
+			"content":  map[string]any{"text": alert.Text()},
+		}
+	}, checkFeishu)
+}
+
+// NewDingTalkSink create a sink posting text messages to a DingTalk custom robot
+func NewDingTalkSink(conf WebhookConfig) *WebhookSink {
+	return newWebhookSink("dingtalk", conf, func(alert *Alert) any {
+		return map[string]any{
+			"msgtype": "text",
+			"text":    map[string]any{"content": alert.Text()},
+		}
+	}, checkDingTalk)
+}
+
+func newWebhookSink(name string, conf WebhookConfig, encode func(alert *Alert) any, check func(body []byte) error) *WebhookSink {
+	if conf.Client == nil {
+		conf.Client = http.DefaultClient
+	}
+	return &WebhookSink{name: name, conf: conf, encode: encode, check: check}
+}
+
+// Name Sink impl
+func (s *WebhookSink) Name() string {
+	return s.name
+}
+
+// Send post the alert to the webhook
+func (s *WebhookSink) Send(ctx context.Context, alert *Alert) error {
+	body, err := json.Marshal(s.encode(alert))
+	if err != nil {
+		return berror.NewInternalError(err, "marshal "+s.name+" alert failed")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.conf.URL, bytes.NewReader(body))
+	if err != nil {
+		return berror.NewInvalidArgument(err, "build "+s.name+" webhook request failed")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.conf.Client.Do(req)
+	if err != nil {
+		return berror.Convert(err, "post "+s.name+" alert failed")
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return berror.NewInternalError(nil, fmt.Sprintf("%s webhook responded with status %d", s.name, resp.StatusCode), string(raw))
+	}
+	if s.check != nil {
+		return s.check(raw)
+	}
+	return nil
+}
+
+// checkFeishu the failures are responded with a non-zero code, such as {"code":19001,"msg":"param invalid"}
+func checkFeishu(body []byte) error {
+	out := &struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}{}
+	if err := json.Unmarshal(body, out); err != nil || out.Code == 0 {
+		return nil
+	}
+	return berror.NewInternalError(nil, fmt.Sprintf("feishu webhook responded with code %d: %s", out.Code, out.Msg))
+}
+
+// checkDingTalk the failures are responded with a non-zero errcode, such as {"errcode":310000,"errmsg":"keywords not in content"}
+func checkDingTalk(body []byte) error {
+	out := &struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}{}
+	if err := json.Unmarshal(body, out); err != nil || out.ErrCode == 0 {
+		return nil
+	}
+	return berror.NewInternalError(nil, fmt.Sprintf("dingtalk webhook responded with errcode %d: %s", out.ErrCode, out.ErrMsg))
+}
@@ -0,0 +1,88 @@
+package balert_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lamber92/go-brick/balert"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/internal/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAlert() *balert.Alert {
+	return &balert.Alert{
+		Service:     "order",
+		Source:      balert.SourceLog,
+		Fingerprint: "abc123",
+		Code:        500,
+		Level:       bcode.LvCritical,
+		LevelName:   "critical",
+		Reason:      "db down",
+		Message:     "query order fail",
+		Error:       `{"code":500,"reason":"db down"}`,
+		Time:        time.Date(2023, 5, 16, 14, 18, 10, 0, time.UTC),
+	}
+}
+
+// serve record the body of the last request and respond with status and resp
+func serve(status int, resp string) (*httptest.Server, *map[string]any) {
+	received := map[string]any{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = map[string]any{}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(resp))
+	}))
+	return srv, &received
+}
+
+func TestWebhookSink(t *testing.T) {
+	text := newAlert().Text()
+	assert.Contains(t, text, "[order] [CRITICAL] db down\nmessage: query order fail\ncode: 500")
+
+	srv, received := serve(http.StatusOK, "")
+	defer srv.Close()
+
+	sink := balert.NewJSONSink(balert.WebhookConfig{URL: srv.URL})
+	assert.Equal(t, "json", sink.Name())
+	assert.NoError(t, sink.Send(context.Background(), newAlert()))
+	assert.Equal(t, "abc123", (*received)["fingerprint"])
+	assert.Equal(t, "critical", (*received)["level"])
+	assert.Equal(t, "log", (*received)["source"])
+
+	assert.NoError(t, balert.NewSlackSink(balert.WebhookConfig{URL: srv.URL}).Send(context.Background(), newAlert()))
+	assert.Equal(t, map[string]any{"text": text}, *received)
+
+	assert.NoError(t, balert.NewFeishuSink(balert.WebhookConfig{URL: srv.URL}).Send(context.Background(), newAlert()))
+	assert.Equal(t, map[string]any{"msg_type": "text", "content": map[string]any{"text": text}}, *received)
+
+	assert.NoError(t, balert.NewDingTalkSink(balert.WebhookConfig{URL: srv.URL}).Send(context.Background(), newAlert()))
+	assert.Equal(t, map[string]any{"msgtype": "text", "text": map[string]any{"content": text}}, *received)
+}
+
+func TestWebhookSink_Fail(t *testing.T) {
+	srv, _ := serve(http.StatusBadRequest, "invalid_payload")
+	defer srv.Close()
+	err := balert.NewSlackSink(balert.WebhookConfig{URL: srv.URL}).Send(context.Background(), newAlert())
+	assert.ErrorContains(t, err, "slack webhook responded with status 400")
+
+	feishu, _ := serve(http.StatusOK, `{"code":19001,"msg":"param invalid"}`)
+	defer feishu.Close()
+	err = balert.NewFeishuSink(balert.WebhookConfig{URL: feishu.URL}).Send(context.Background(), newAlert())
+	assert.ErrorContains(t, err, "feishu webhook responded with code 19001: param invalid")
+
+	dingtalk, _ := serve(http.StatusOK, `{"errcode":310000,"errmsg":"keywords not in content"}`)
+	defer dingtalk.Close()
+	err = balert.NewDingTalkSink(balert.WebhookConfig{URL: dingtalk.URL}).Send(context.Background(), newAlert())
+	assert.ErrorContains(t, err, "dingtalk webhook responded with errcode 310000")
+
+	ok, _ := serve(http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	defer ok.Close()
+	assert.NoError(t, balert.NewDingTalkSink(balert.WebhookConfig{URL: ok.URL}).Send(context.Background(), newAlert()))
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/apolloconfig/agollo/v4/perror"
 	"github.com/lamber92/go-brick/bconfig/bstorage"
 	"github.com/lamber92/go-brick/berror"
-	"github.com/lamber92/go-brick/btrace"
 )
 
 const (
@@ -77,6 +76,11 @@ func (a *apolloConfig) Load(ctx context.Context, key string, namespace ...string
 	if len(namespace) > 0 {
 		ns = namespace[0]
 	}
+	span := startSpan(ctx, ns, key)
+	defer func() {
+		endSpan(span, out, err)
+	}()
+
 	conf := a.client.GetConfig(ns)
 	if conf == nil {
 		err = berror.NewNotFound(nil, fmt.Sprintf("cannot find key in Apollo. namespace: %s | key: %s", ns, key))
@@ -91,11 +95,6 @@ func (a *apolloConfig) Load(ctx context.Context, key string, namespace ...string
 		return
 	}
 	out = newDefaultValue(v)
-	defer func() {
-		if err == nil {
-			btrace.AppendMDIntoCtx(ctx, newMetadata(ns, key, out))
-		}
-	}()
 	return
 }
 
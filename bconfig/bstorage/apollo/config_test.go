@@ -2,14 +2,14 @@ package apollo_test
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/lamber92/go-brick/bconfig/bstorage/apollo"
 	"github.com/lamber92/go-brick/bconfig/bstorage/yaml"
-	"github.com/lamber92/go-brick/berror"
-	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
 )
 
 func TestLoadDefaultNSConfig(t *testing.T) {
@@ -122,7 +122,7 @@ func TestLoadInvalidNamespace(t *testing.T) {
 
 	value, err := config.Load(context.Background(), "Server.Access", "invalid_namespace")
 	if err != nil {
-		if err.(berror.Error).Status().Code().Is(bcode.NotFound) {
+		if errors.Is(err, bstatus.NotFound) {
 			t.Log(err)
 		} else {
 			t.Fatal(err)
@@ -149,7 +149,7 @@ func TestLoadInvalidKey(t *testing.T) {
 
 	value, err := config.Load(context.Background(), "invalid key")
 	if err != nil {
-		if err.(berror.Error).Status().Code().Is(bcode.NotFound) {
+		if errors.Is(err, bstatus.NotFound) {
 			t.Log(err)
 		} else {
 			t.Fatal(err)
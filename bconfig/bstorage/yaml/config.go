@@ -9,7 +9,6 @@ import (
 	"github.com/lamber92/go-brick/bconfig/bstorage"
 	"github.com/lamber92/go-brick/berror"
 	"github.com/lamber92/go-brick/blog/logger"
-	"github.com/lamber92/go-brick/btrace"
 	"github.com/lamber92/go-brick/internal/bufferpool"
 	bsync "github.com/lamber92/go-brick/internal/sync"
 	"github.com/spf13/viper"
@@ -73,10 +72,9 @@ func (c *yamlConfig) Load(ctx context.Context, key string, filenames ...string)
 	if len(filenames) > 0 {
 		filename = filenames[0]
 	}
+	span := startSpan(ctx, filename, key)
 	defer func() {
-		if err == nil {
-			btrace.AppendMDIntoCtx(ctx, newMetadata(filename, key, out))
-		}
+		endSpan(span, out, err)
 	}()
 
 	// try to get from cache
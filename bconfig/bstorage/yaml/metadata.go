@@ -1,49 +1,33 @@
 package yaml
 
 import (
+	"context"
+
 	"github.com/lamber92/go-brick/bconfig/bstorage"
 	"github.com/lamber92/go-brick/btrace"
-	"github.com/lamber92/go-brick/internal/json"
-	"go.uber.org/zap/zapcore"
 )
 
 const (
 	traceModule btrace.Module = "yaml_config"
 )
 
-func newMetadata(namespace, k string, v bstorage.Value) *defaultMD {
-	return &defaultMD{
-		ModuleName: traceModule,
-		TypeName:   "yaml",
-		Namespace:  namespace,
-		Key:        k,
-		// the value pointed by the pointer may change, here must be a mirror image
-		Value: v.String(),
-	}
-}
-
-type defaultMD struct {
-	ModuleName btrace.Module `json:"module"`
-	TypeName   string        `json:"type"`
-	Namespace  string        `json:"namespace"`
-	Key        string        `json:"key"`
-	Value      string        `json:"value"`
+// startSpan start a span of loading the key from the namespace
+func startSpan(ctx context.Context, namespace, k string) btrace.Span {
+	_, span := btrace.StartSpan(ctx, string(traceModule),
+		btrace.Attr("type", "yaml"),
+		btrace.Attr("namespace", namespace),
+		btrace.Attr("key", k),
+	)
+	return span
 }
 
-func (m *defaultMD) Module() btrace.Module {
-	return m.ModuleName
-}
-
-func (m *defaultMD) String() string {
-	out, _ := json.MarshalToString(m)
-	return out
-}
-
-func (m *defaultMD) MarshalLogObject(enc zapcore.ObjectEncoder) error {
-	enc.AddString("module", string(m.ModuleName))
-	enc.AddString("type", m.TypeName)
-	enc.AddString("namespace", m.Namespace)
-	enc.AddString("key", m.Key)
-	enc.AddString("value", m.Value)
-	return nil
+// endSpan record the loading result and finish the span
+func endSpan(span btrace.Span, v bstorage.Value, err error) {
+	if err != nil {
+		span.SetError(err)
+	} else {
+		// the value pointed by the pointer may change, here must be a mirror image
+		span.SetAttributes(btrace.Attr("value", v.String()))
+	}
+	span.End()
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/lamber92/go-brick/bconfig/bstorage"
 	"github.com/lamber92/go-brick/bconfig/bstorage/apollo"
 	"github.com/lamber92/go-brick/bconfig/bstorage/yaml"
+	"github.com/lamber92/go-brick/berror"
 )
 
 var (
@@ -46,6 +47,8 @@ func Init(opt Option) {
 		if err != nil {
 			panic(err)
 		}
+		// let the stack policy of berror know the environment
+		berror.ReplaceAllowDebug(_env.AllowDebug)
 		if len(opt.ConfigDir) > 0 {
 			yaml.InitRootDir(opt.ConfigDir)
 		}
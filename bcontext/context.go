@@ -2,70 +2,134 @@ package bcontext
 
 import (
 	"context"
-	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// defaultContext
+// all mutable states are stored behind atomic pointers,
+// readers (Value/Done/Deadline/Err) never take a lock.
 type defaultContext struct {
 	orig context.Context
-	kv   map[string]any
 
-	timer  context.Context
-	cancel context.CancelFunc
+	// kv immutable key-value snapshot, replaced as a whole on every Set (copy-on-write).
+	// nil means that nothing has been stored yet.
+	kv atomic.Pointer[kvStore]
+	// timer the timeout/cancel controller currently in effect.
+	// nil means that no timeout/cancel has been set yet.
+	timer atomic.Pointer[timerState]
+}
+
+// kvStoreMaxPairs
+// below this size the snapshot is a plain slice, which is cheaper to copy and scan than a map.
+const kvStoreMaxPairs = 8
+
+// kvStore read-only key-value snapshot.
+// the map is lazily allocated once the pair count exceeds kvStoreMaxPairs.
+type kvStore struct {
+	pairs []kvPair
+	m     map[string]any
+}
+
+type kvPair struct {
+	key   string
+	value any
+}
+
+func (kv *kvStore) get(key string) (any, bool) {
+	if kv.m != nil {
+		v, ok := kv.m[key]
+		return v, ok
+	}
+	for i := range kv.pairs {
+		if kv.pairs[i].key == key {
+			return kv.pairs[i].value, true
+		}
+	}
+	return nil, false
+}
+
+// with return a new snapshot containing the key-value pair
+func (kv *kvStore) with(key string, value any) *kvStore {
+	if kv == nil {
+		return &kvStore{pairs: []kvPair{{key, value}}}
+	}
+	if kv.m != nil {
+		m := make(map[string]any, len(kv.m)+1)
+		for k, v := range kv.m {
+			m[k] = v
+		}
+		m[key] = value
+		return &kvStore{m: m}
+	}
+	for i := range kv.pairs {
+		if kv.pairs[i].key == key {
+			pairs := make([]kvPair, len(kv.pairs))
+			copy(pairs, kv.pairs)
+			pairs[i].value = value
+			return &kvStore{pairs: pairs}
+		}
+	}
+	if len(kv.pairs) < kvStoreMaxPairs {
+		pairs := make([]kvPair, len(kv.pairs), len(kv.pairs)+1)
+		copy(pairs, kv.pairs)
+		return &kvStore{pairs: append(pairs, kvPair{key, value})}
+	}
+	m := make(map[string]any, len(kv.pairs)+1)
+	for _, p := range kv.pairs {
+		m[p.key] = p.value
+	}
+	m[key] = value
+	return &kvStore{m: m}
+}
 
-	sync.RWMutex
+// timerState read-only timeout/cancel controller
+type timerState struct {
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 func New() Context {
 	return &defaultContext{
 		orig: nil,
-		kv:   make(map[string]any),
 	}
 }
 
 func NewWithCtx(ctx context.Context) Context {
 	out := &defaultContext{
 		orig: ctx,
-		kv:   make(map[string]any),
 	}
 	if deadline, exist := ctx.Deadline(); exist {
-		out.timer, out.cancel = context.WithDeadline(out, deadline)
+		timer, cancel := context.WithDeadline(out, deadline)
+		out.timer.Store(&timerState{ctx: timer, cancel: cancel})
 	}
 	return out
 }
 
 // WithTimeout override context timeout/cancel
 func (ctx *defaultContext) WithTimeout(timeout time.Duration) {
-	ctx.Lock()
-	defer ctx.Unlock()
-	_, origCancel := ctx.timer, ctx.cancel
-	// reset timeout
-	ctx.timer, ctx.cancel = context.WithTimeout(context.Background(), timeout)
-	if origCancel != nil {
-		// release original timer
-		origCancel()
-	}
-	return
+	timer, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx.resetTimer(&timerState{ctx: timer, cancel: cancel})
 }
 
 // WithCancel override context timeout/cancel
 func (ctx *defaultContext) WithCancel() {
-	ctx.Lock()
-	defer ctx.Unlock()
-	_, origCancel := ctx.timer, ctx.cancel
-	// reset timeout
-	ctx.timer, ctx.cancel = context.WithCancel(context.Background())
-	if origCancel != nil {
+	timer, cancel := context.WithCancel(context.Background())
+	ctx.resetTimer(&timerState{ctx: timer, cancel: cancel})
+}
+
+// resetTimer replace the current timer and release the original one
+func (ctx *defaultContext) resetTimer(ts *timerState) {
+	if orig := ctx.timer.Swap(ts); orig != nil && orig.cancel != nil {
 		// release original timer
-		origCancel()
+		orig.cancel()
 	}
-	return
 }
 
 // Cancel trigger context timeout early
 func (ctx *defaultContext) Cancel() {
-	if ctx.cancel != nil {
-		ctx.cancel()
+	if ts := ctx.timer.Load(); ts != nil && ts.cancel != nil {
+		ts.cancel()
 	}
 }
 
@@ -79,53 +143,48 @@ func (ctx *defaultContext) GetOrigCtx() (context.Context, bool) {
 }
 
 func (ctx *defaultContext) Set(key string, value any) Context {
-	ctx.Lock()
-	ctx.kv[key] = value
-	ctx.Unlock()
-	return ctx
+	for {
+		old := ctx.kv.Load()
+		if ctx.kv.CompareAndSwap(old, old.with(key, value)) {
+			return ctx
+		}
+	}
 }
 
 func (ctx *defaultContext) Get(key string) (value any, exists bool) {
-	ctx.RLock()
-	value, exists = ctx.kv[key]
-	ctx.RUnlock()
+	if kv := ctx.kv.Load(); kv != nil {
+		value, exists = kv.get(key)
+	}
 	return
 }
 
 func (ctx *defaultContext) Deadline() (deadline time.Time, ok bool) {
-	ctx.RLock()
-	defer ctx.RUnlock()
-	if ctx.timer != nil {
-		deadline, ok = ctx.timer.Deadline()
-		return
+	if ts := ctx.timer.Load(); ts != nil {
+		deadline, ok = ts.ctx.Deadline()
 	}
 	return
 }
 
 func (ctx *defaultContext) Done() <-chan struct{} {
-	ctx.RLock()
-	defer ctx.RUnlock()
-	if ctx.timer != nil {
-		return ctx.timer.Done()
+	if ts := ctx.timer.Load(); ts != nil {
+		return ts.ctx.Done()
 	}
 	return nil
 }
 
 func (ctx *defaultContext) Err() error {
-	ctx.RLock()
-	defer ctx.RUnlock()
-	if ctx.timer != nil {
-		return ctx.timer.Err()
+	if ts := ctx.timer.Load(); ts != nil {
+		return ts.ctx.Err()
 	}
 	return context.Canceled
 }
 
 func (ctx *defaultContext) Value(key any) any {
-	ctx.RLock()
-	defer ctx.RUnlock()
 	if strKey, ok := key.(string); ok {
-		if val, exist := ctx.kv[strKey]; exist {
-			return val
+		if kv := ctx.kv.Load(); kv != nil {
+			if val, exist := kv.get(strKey); exist {
+				return val
+			}
 		}
 	}
 	if ctx.orig != nil {
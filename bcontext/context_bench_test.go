@@ -0,0 +1,164 @@
+package bcontext
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rwMutexContext the previous RWMutex based implementation,
+// kept here only as the benchmark baseline.
+type rwMutexContext struct {
+	orig context.Context
+	kv   map[string]any
+
+	timer  context.Context
+	cancel context.CancelFunc
+
+	sync.RWMutex
+}
+
+func newRWMutexContext(ctx context.Context) *rwMutexContext {
+	return &rwMutexContext{
+		orig: ctx,
+		kv:   make(map[string]any),
+	}
+}
+
+func (ctx *rwMutexContext) WithTimeout(timeout time.Duration) {
+	ctx.Lock()
+	defer ctx.Unlock()
+	origCancel := ctx.cancel
+	ctx.timer, ctx.cancel = context.WithTimeout(context.Background(), timeout)
+	if origCancel != nil {
+		origCancel()
+	}
+}
+
+func (ctx *rwMutexContext) Set(key string, value any) {
+	ctx.Lock()
+	ctx.kv[key] = value
+	ctx.Unlock()
+}
+
+func (ctx *rwMutexContext) Deadline() (deadline time.Time, ok bool) {
+	ctx.RLock()
+	defer ctx.RUnlock()
+	if ctx.timer != nil {
+		deadline, ok = ctx.timer.Deadline()
+	}
+	return
+}
+
+func (ctx *rwMutexContext) Done() <-chan struct{} {
+	ctx.RLock()
+	defer ctx.RUnlock()
+	if ctx.timer != nil {
+		return ctx.timer.Done()
+	}
+	return nil
+}
+
+func (ctx *rwMutexContext) Value(key any) any {
+	ctx.RLock()
+	defer ctx.RUnlock()
+	if strKey, ok := key.(string); ok {
+		if val, exist := ctx.kv[strKey]; exist {
+			return val
+		}
+	}
+	if ctx.orig != nil {
+		return ctx.orig.Value(key)
+	}
+	return nil
+}
+
+type benchContext interface {
+	WithTimeout(timeout time.Duration)
+	Set(key string, value any)
+	Deadline() (deadline time.Time, ok bool)
+	Done() <-chan struct{}
+	Value(key any) any
+}
+
+type benchSetter struct {
+	Context
+}
+
+func (s benchSetter) Set(key string, value any) {
+	s.Context.Set(key, value)
+}
+
+var benchImpls = []struct {
+	name string
+	new  func() benchContext
+}{
+	{"RWMutex", func() benchContext { return newRWMutexContext(context.Background()) }},
+	{"Atomic", func() benchContext { return benchSetter{NewWithCtx(context.Background())} }},
+}
+
+func prepareBenchContext(ctx benchContext) {
+	for i := 0; i < 8; i++ {
+		ctx.Set("key_"+strconv.Itoa(i), i)
+	}
+	ctx.WithTimeout(time.Minute)
+}
+
+var benchSink any
+
+func BenchmarkContext_New(b *testing.B) {
+	for _, impl := range benchImpls {
+		b.Run(impl.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				benchSink = impl.new()
+			}
+		})
+	}
+}
+
+func BenchmarkContext_Value(b *testing.B) {
+	for _, impl := range benchImpls {
+		ctx := impl.new()
+		prepareBenchContext(ctx)
+		b.Run(impl.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = ctx.Value("key_3")
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkContext_Done(b *testing.B) {
+	for _, impl := range benchImpls {
+		ctx := impl.new()
+		prepareBenchContext(ctx)
+		b.Run(impl.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = ctx.Done()
+					_, _ = ctx.Deadline()
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkContext_Set(b *testing.B) {
+	for _, impl := range benchImpls {
+		ctx := impl.new()
+		prepareBenchContext(ctx)
+		b.Run(impl.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ctx.Set("key_"+strconv.Itoa(i&7), i)
+			}
+		})
+	}
+}
@@ -1,5 +1,11 @@
 package bcontext
 
 const (
-	TraceChain = "b_trace_chain"
+	TraceChain    = "b_trace_chain"
+	TraceID       = "b_trace_id"
+	TraceContext  = "b_trace_context"
+	TraceSpan     = "b_trace_span"
+	TraceSampling = "b_trace_sampling"
+
+	Locale = "b_locale"
 )
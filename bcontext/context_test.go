@@ -2,6 +2,8 @@ package bcontext_test
 
 import (
 	"context"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -80,8 +82,8 @@ func TestNewWithCtxTimeout(t *testing.T) {
 	begin := time.Now()
 	sec := time.Second * 5
 
-	orig := context.Background()
-	orig, _ = context.WithTimeout(orig, sec)
+	orig, cancel := context.WithTimeout(context.Background(), sec)
+	defer cancel()
 
 	// test Err()
 	ctx := bcontext.NewWithCtx(orig)
@@ -167,3 +169,51 @@ func TestCtxValue(t *testing.T) {
 	assert.Equal(t, ctx, ctx3)
 	assert.Equal(t, value, ctx3.Value(key))
 }
+
+func TestConcurrentSetValueWithTimeout(t *testing.T) {
+	ctx := bcontext.NewWithCtx(context.WithValue(context.Background(), "orig", "orig"))
+
+	var (
+		wg    = sync.WaitGroup{}
+		count = 100
+	)
+	wg.Add(count * 3)
+	for i := 0; i < count; i++ {
+		ii := i
+		go func() {
+			defer wg.Done()
+			ctx.Set(strconv.Itoa(ii), ii)
+		}()
+		go func() {
+			defer wg.Done()
+			_ = ctx.Value(strconv.Itoa(ii))
+			_ = ctx.Value("orig")
+			_, _ = ctx.Deadline()
+			_ = ctx.Done()
+			_ = ctx.Err()
+		}()
+		go func() {
+			defer wg.Done()
+			if ii%2 == 0 {
+				ctx.WithTimeout(time.Minute)
+			} else {
+				ctx.WithCancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// every Set must survive the concurrent copy-on-write
+	for i := 0; i < count; i++ {
+		v, ok := ctx.Get(strconv.Itoa(i))
+		assert.Equal(t, true, ok)
+		assert.Equal(t, i, v)
+	}
+	assert.Equal(t, "orig", ctx.Value("orig"))
+
+	// the last installed timer is still in effect
+	assert.Equal(t, nil, ctx.Err())
+	ctx.Cancel()
+	<-ctx.Done()
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
@@ -12,6 +12,7 @@ import (
 	"sync"
 
 	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror"
 	"github.com/lamber92/go-brick/bpanic"
 )
 
@@ -31,6 +32,9 @@ type Group struct {
 
 	errOnce sync.Once
 	err     error
+
+	errMu sync.Mutex
+	errs  []error
 }
 
 func (g *Group) done() {
@@ -41,6 +45,9 @@ func (g *Group) done() {
 }
 
 func (g *Group) handleError(err error) {
+	g.errMu.Lock()
+	g.errs = append(g.errs, err)
+	g.errMu.Unlock()
 	g.errOnce.Do(func() {
 		g.err = err
 		if g.ctx != nil {
@@ -76,6 +83,18 @@ func (g *Group) Wait() error {
 	return g.err
 }
 
+// WaitAll blocks until all function calls from the Go method have returned, then
+// returns all the non-nil errors from them joined by berror.Join, in the order they were returned.
+func (g *Group) WaitAll() error {
+	g.wg.Wait()
+	if g.ctx != nil {
+		g.ctx.Cancel()
+	}
+	g.errMu.Lock()
+	defer g.errMu.Unlock()
+	return berror.Join(g.errs...)
+}
+
 // Go calls the given function in a new goroutine.
 // It blocks until the new goroutine can be added without the number of
 // active goroutines in the group exceeding the configured limit.
@@ -97,7 +116,9 @@ func (g *Group) Go(f func() error, hook ...func(error)) {
 		defer bpanic.Recover(func(err error) {
 			if err != nil {
 				g.handleError(err)
-				handle(err)
+				if handle != nil {
+					handle(err)
+				}
 			}
 		})
 		defer g.done()
@@ -132,7 +153,9 @@ func (g *Group) TryGo(f func() error, hook ...func(error)) bool {
 		defer bpanic.Recover(func(err error) {
 			if err != nil {
 				g.handleError(err)
-				handle(err)
+				if handle != nil {
+					handle(err)
+				}
 			}
 		})
 		defer g.done()
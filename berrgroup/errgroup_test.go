@@ -257,7 +257,6 @@ func TestGoPanicRecover(t *testing.T) {
 	g.Go(func() error {
 		time.Sleep(time.Second * 3)
 		panic("xxxxx")
-		return nil
 	}, func(err error) {
 		blog.Warn(ctx, err, "")
 	})
@@ -287,3 +286,23 @@ func BenchmarkGo(b *testing.B) {
 	}
 	g.Wait()
 }
+
+func TestWaitAll(t *testing.T) {
+	err1 := errors.New("berrgroup_test: 1")
+	err2 := errors.New("berrgroup_test: 2")
+
+	g := new(berrgroup.Group)
+	g.Go(func() error { return err1 })
+	g.Go(func() error { return nil })
+	g.Go(func() error { return err2 })
+	err := g.WaitAll()
+	if !errors.Is(err, err1) || !errors.Is(err, err2) {
+		t.Errorf("g.WaitAll() = %v; want both %v and %v", err, err1, err2)
+	}
+
+	g = new(berrgroup.Group)
+	g.Go(func() error { return nil })
+	if err = g.WaitAll(); err != nil {
+		t.Errorf("g.WaitAll() = %v; want nil", err)
+	}
+}
@@ -0,0 +1,241 @@
+package berror
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/bstack"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultAggregatorMaxRecords = 1024
+
+	modulePath = "github.com/lamber92/go-brick"
+)
+
+// fingerprintStackOptions the stack of the caller identifying an error that is not Error.
+// the frames of the packages that fingerprint on behalf of their callers are dropped,
+// so that the same call site gets the same fingerprint whether it goes through the logger, the notifier or neither.
+var fingerprintStackOptions = bstack.Options{
+	Filters: []bstack.FrameFilter{
+		bstack.DropRuntime(),
+		func(frame runtime.Frame) bool {
+			for _, pkg := range []string{"/berror.", "/blog.", "/blog/logger.", "/balert.", "/bpanic."} {
+				if strings.HasPrefix(frame.Function, modulePath+pkg) {
+					return false
+				}
+			}
+			return true
+		},
+	},
+}
+
+// Fingerprint identify the errors that come from the same place:
+// the code combined with the fingerprint of the stack (see bstack.StackList.Fingerprint).
+// an error that is not Error carries no stack, it is identified by its type, its message and the stack of the caller.
+// returns an empty string for a nil error.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+	h := fnv.New64a()
+	if e, ok := asError(err); ok {
+		_, _ = h.Write([]byte(strconv.Itoa(CodeOf(err).ToInt())))
+		_, _ = h.Write([]byte{'|'})
+		_, _ = h.Write([]byte(e.Stack().Fingerprint()))
+	} else {
+		_, _ = h.Write([]byte(strconv.Itoa(bcode.Unknown.ToInt())))
+		_, _ = h.Write([]byte{'|'})
+		_, _ = h.Write([]byte(fmt.Sprintf("%T", err)))
+		_, _ = h.Write([]byte{'|'})
+		_, _ = h.Write([]byte(err.Error()))
+		_, _ = h.Write([]byte{'|'})
+		_, _ = h.Write([]byte(bstack.TakeStackWithOptions(1, fingerprintStackOptions).Fingerprint()))
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// AggregateRecord the occurrences of the errors of a fingerprint
+type AggregateRecord struct {
+	Fingerprint string
+	Code        bcode.Code
+	// Count the total number of occurrences
+	Count uint64
+	// Pending the number of occurrences since the last Flush
+	Pending   uint64
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// Sample the most recent error
+	Sample error
+}
+
+// MarshalLogObject zapcore.ObjectMarshaler impl
+func (r *AggregateRecord) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("fingerprint", r.Fingerprint)
+	if r.Code != nil {
+		enc.AddInt("code", r.Code.ToInt())
+	}
+	enc.AddUint64("count", r.Count)
+	enc.AddUint64("pending", r.Pending)
+	enc.AddTime("first_seen", r.FirstSeen)
+	enc.AddTime("last_seen", r.LastSeen)
+	if r.Sample != nil {
+		if obj, ok := r.Sample.(zapcore.ObjectMarshaler); ok {
+			return enc.AddObject("sample", obj)
+		}
+		enc.AddString("sample", r.Sample.Error())
+	}
+	return nil
+}
+
+// AggregateRecordList records ordered by count
+type AggregateRecordList []*AggregateRecord
+
+// MarshalLogArray zapcore.ArrayMarshaler impl
+func (l AggregateRecordList) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, r := range l {
+		if err := enc.AppendObject(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Aggregator count the errors by fingerprint in process,
+// so that an error that happens repeatedly can be logged once and summarized periodically.
+type Aggregator struct {
+	records    map[string]*AggregateRecord
+	maxRecords int
+	sync.Mutex
+}
+
+// NewAggregator create an aggregator that keeps at most maxRecords fingerprints,
+// the least recently seen one is evicted when it is full. defaults to 1024.
+func NewAggregator(maxRecords int) *Aggregator {
+	if maxRecords <= 0 {
+		maxRecords = defaultAggregatorMaxRecords
+	}
+	return &Aggregator{
+		records:    make(map[string]*AggregateRecord),
+		maxRecords: maxRecords,
+	}
+}
+
+// Add count an occurrence of the error.
+// returns a copy of the record and 'true' if the fingerprint is seen for the first time.
+func (a *Aggregator) Add(err error) (AggregateRecord, bool) {
+	if err == nil {
+		return AggregateRecord{}, false
+	}
+	fp := Fingerprint(err)
+	now := time.Now()
+
+	a.Lock()
+	defer a.Unlock()
+	rec, exist := a.records[fp]
+	if !exist {
+		if len(a.records) >= a.maxRecords {
+			a.evict()
+		}
+		rec = &AggregateRecord{Fingerprint: fp, Code: CodeOf(err), FirstSeen: now}
+		a.records[fp] = rec
+	}
+	rec.Count++
+	rec.Pending++
+	rec.LastSeen = now
+	rec.Sample = err
+	return *rec, !exist
+}
+
+// evict drop the least recently seen record
+func (a *Aggregator) evict() {
+	var oldest *AggregateRecord
+	for _, r := range a.records {
+		if oldest == nil || r.LastSeen.Before(oldest.LastSeen) {
+			oldest = r
+		}
+	}
+	if oldest != nil {
+		delete(a.records, oldest.Fingerprint)
+	}
+}
+
+// Get return a copy of the record of the fingerprint
+func (a *Aggregator) Get(fingerprint string) (AggregateRecord, bool) {
+	a.Lock()
+	defer a.Unlock()
+	if rec, ok := a.records[fingerprint]; ok {
+		return *rec, true
+	}
+	return AggregateRecord{}, false
+}
+
+// Records return copies of all the records, the most frequent first
+func (a *Aggregator) Records() AggregateRecordList {
+	a.Lock()
+	defer a.Unlock()
+	out := make(AggregateRecordList, 0, len(a.records))
+	for _, r := range a.records {
+		tmp := *r
+		out = append(out, &tmp)
+	}
+	sortRecords(out)
+	return out
+}
+
+// Flush return copies of the records that occurred since the last Flush, the most frequent first,
+// and reset their Pending.
+func (a *Aggregator) Flush() AggregateRecordList {
+	a.Lock()
+	defer a.Unlock()
+	out := make(AggregateRecordList, 0)
+	for _, r := range a.records {
+		if r.Pending == 0 {
+			continue
+		}
+		tmp := *r
+		out = append(out, &tmp)
+		r.Pending = 0
+	}
+	sortRecords(out)
+	return out
+}
+
+// Report call emit with the flushed records every interval until ctx is done,
+// the records are flushed for the last time before it returns.
+// nb. it blocks, run it in a goroutine.
+func (a *Aggregator) Report(ctx context.Context, interval time.Duration, emit func(records AggregateRecordList)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if records := a.Flush(); len(records) > 0 {
+				emit(records)
+			}
+			return
+		case <-ticker.C:
+			if records := a.Flush(); len(records) > 0 {
+				emit(records)
+			}
+		}
+	}
+}
+
+func sortRecords(list AggregateRecordList) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Fingerprint < list[j].Fingerprint
+	})
+}
@@ -0,0 +1,111 @@
+package berror_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func newLoopErrors(n int) []error {
+	out := make([]error, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, berror.NewInternalError(nil, "internal error"))
+	}
+	return out
+}
+
+func TestFingerprint(t *testing.T) {
+	errs := newLoopErrors(2)
+	assert.Equal(t, berror.Fingerprint(errs[0]), berror.Fingerprint(errs[1]))
+	// the code counts
+	assert.NotEqual(t, berror.Fingerprint(errs[0]), berror.Fingerprint(berror.NewNotFound(errs[0], "not found")))
+	// so does the stack
+	assert.NotEqual(t, berror.Fingerprint(errs[0]), berror.Fingerprint(berror.NewInternalError(nil, "internal error")))
+	// not Error, identified by the message and the call site
+	assert.NotEqual(t, berror.Fingerprint(errors.New("db down")), berror.Fingerprint(errors.New("disk full")))
+	plain := make([]string, 0, 2)
+	for i := 0; i < 2; i++ {
+		plain = append(plain, berror.Fingerprint(fmt.Errorf("query fail: %w", errors.New("db down"))))
+	}
+	assert.Equal(t, plain[0], plain[1])
+	assert.NotEqual(t, plain[0], fingerprintElsewhere(fmt.Errorf("query fail: %w", errors.New("db down"))))
+	assert.Len(t, plain[0], 16)
+	assert.Equal(t, "", berror.Fingerprint(nil))
+}
+
+func fingerprintElsewhere(err error) string {
+	return berror.Fingerprint(err)
+}
+
+func TestAggregator(t *testing.T) {
+	agg := berror.NewAggregator(2)
+	errs := newLoopErrors(3)
+	rec, first := agg.Add(errs[0])
+	assert.Equal(t, true, first)
+	_, first = agg.Add(errs[1])
+	assert.Equal(t, false, first)
+	rec, _ = agg.Add(errs[2])
+	assert.Equal(t, uint64(3), rec.Count)
+	assert.Equal(t, bcode.InternalError, rec.Code)
+	assert.Equal(t, errs[2], rec.Sample)
+	assert.Equal(t, false, rec.LastSeen.Before(rec.FirstSeen))
+
+	other := errors.New("other")
+	agg.Add(other)
+	got, ok := agg.Get(berror.Fingerprint(other))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, uint64(1), got.Count)
+
+	records := agg.Records()
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, uint64(3), records[0].Count)
+
+	// pending occurrences are reported once
+	flushed := agg.Flush()
+	assert.Equal(t, 2, len(flushed))
+	assert.Equal(t, uint64(3), flushed[0].Pending)
+	assert.Equal(t, 0, len(agg.Flush()))
+	agg.Add(errs[0])
+	flushed = agg.Flush()
+	assert.Equal(t, 1, len(flushed))
+	assert.Equal(t, uint64(4), flushed[0].Count)
+	assert.Equal(t, uint64(1), flushed[0].Pending)
+
+	// the least recently seen is evicted
+	agg.Add(berror.NewNotFound(nil, "not found"))
+	_, ok = agg.Get(berror.Fingerprint(other))
+	assert.Equal(t, false, ok)
+
+	enc := zapcore.NewMapObjectEncoder()
+	assert.Nil(t, enc.AddArray("errors", agg.Records()))
+	assert.Equal(t, 2, len(enc.Fields["errors"].([]any)))
+}
+
+func TestAggregatorReport(t *testing.T) {
+	agg := berror.NewAggregator(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	reported := make(chan berror.AggregateRecordList, 4)
+	done := make(chan struct{})
+	go func() {
+		agg.Report(ctx, 20*time.Millisecond, func(records berror.AggregateRecordList) { reported <- records })
+		close(done)
+	}()
+	for _, err := range newLoopErrors(5) {
+		agg.Add(err)
+	}
+	records := <-reported
+	assert.Equal(t, uint64(5), records[0].Pending)
+
+	agg.Add(errors.New("last"))
+	cancel()
+	<-done
+	records = <-reported
+	assert.Equal(t, 1, len(records))
+}
@@ -1,45 +1,79 @@
 package bcode
 
 import (
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 )
 
-type defaultCode int
+// defaultCode Code impl, it implements error as well, so that the codes can be the targets of errors.Is,
+// such as errors.Is(err, bcode.NotFound).
+type defaultCode struct {
+	codeValue
+}
+
+// codeValue the value of defaultCode.
+// nb. Is(any) is declared on it rather than on defaultCode, an error type is expected to declare Is(error).
+type codeValue int
 
 // New create a defaultCode object
 func New(code int) Code {
-	return defaultCode(code)
+	return defaultCode{codeValue(code)}
 }
 
 // internal Error Code preset value
 // nb. it is filled with the value of http-status-code, which has nothing to do with the purpose of http-status-code
-const (
-	Unknown            defaultCode = -1                             // -1:Unknown error
-	OK                 defaultCode = 0                              // 200:Success
-	InvalidArgument    defaultCode = http.StatusBadRequest          // 400:Invalid parameters
-	Unauthorized       defaultCode = http.StatusUnauthorized        // 401:User login authentication failed
-	Forbidden          defaultCode = http.StatusForbidden           // 403:Request denied
-	NotFound           defaultCode = http.StatusNotFound            // 404:Resource not found
-	RequestTimeout     defaultCode = http.StatusRequestTimeout      // 408:Request timeout
-	ClientClosed       defaultCode = 499                            // 499:Client connection closed
-	InternalError      defaultCode = http.StatusInternalServerError // 500:Internal server error
-	ServiceUnavailable defaultCode = http.StatusServiceUnavailable  // 503:Service unavailable
-	GatewayTimeout     defaultCode = http.StatusGatewayTimeout      // 504:Gateway timeout
-	AlreadyExists      defaultCode = 614                            // 614:Resource already exists
+//
+// nb. they are variables rather than constants, so that they implement error and can be the targets of errors.Is.
+// this is a breaking change: they cannot be used in constant expressions any more, use ToInt() instead.
+// never reassign them, the mappings of bcode and bstatus are keyed by their values. TestPresetCodes guards it.
+var (
+	Unknown            = defaultCode{-1}                             // -1:Unknown error
+	OK                 = defaultCode{0}                              // 200:Success
+	InvalidArgument    = defaultCode{http.StatusBadRequest}          // 400:Invalid parameters
+	Unauthorized       = defaultCode{http.StatusUnauthorized}        // 401:User login authentication failed
+	Forbidden          = defaultCode{http.StatusForbidden}           // 403:Request denied
+	NotFound           = defaultCode{http.StatusNotFound}            // 404:Resource not found
+	RequestTimeout     = defaultCode{http.StatusRequestTimeout}      // 408:Request timeout
+	ClientClosed       = defaultCode{499}                            // 499:Client connection closed
+	InternalError      = defaultCode{http.StatusInternalServerError} // 500:Internal server error
+	ServiceUnavailable = defaultCode{http.StatusServiceUnavailable}  // 503:Service unavailable
+	GatewayTimeout     = defaultCode{http.StatusGatewayTimeout}      // 504:Gateway timeout
+	AlreadyExists      = defaultCode{614}                            // 614:Resource already exists
 )
 
-func (c defaultCode) ToInt() int {
+// Error error impl, the code value
+func (c defaultCode) Error() string {
+	return c.ToString()
+}
+
+// Format fmt.Formatter impl, the code is printed as an integer
+func (c defaultCode) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'q':
+		_, _ = io.WriteString(s, strconv.Quote(c.ToString()))
+	default:
+		_, _ = io.WriteString(s, c.ToString())
+	}
+}
+
+func (c codeValue) ToInt() int {
 	return int(c)
 }
 
-func (c defaultCode) ToString() string {
+func (c codeValue) ToString() string {
 	return strconv.Itoa(int(c))
 }
 
+// MarshalJSON json.Marshaler impl, the code is encoded as an integer
+func (c codeValue) MarshalJSON() ([]byte, error) {
+	return []byte(c.ToString()), nil
+}
+
 // Is compare the target code value with the current error code value
 // only Code, Integer and String types are supported
-func (c defaultCode) Is(target any) bool {
+func (c codeValue) Is(target any) bool {
 	switch tmp := target.(type) {
 	case Code:
 		return c.ToInt() == tmp.ToInt()
@@ -1,7 +1,13 @@
 package bcode_test
 
 import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/lamber92/go-brick/berror/bcode"
@@ -97,3 +103,76 @@ func TestCustomizedCode(t *testing.T) {
 	assert.Equal(t, false, bcode.OK.Is(myCode{0}))
 	assert.Equal(t, true, bcode.OK.Is(&myCode{0}))
 }
+
+var presetCodes = map[string]int{
+	"Unknown":            -1,
+	"OK":                 0,
+	"InvalidArgument":    400,
+	"Unauthorized":       401,
+	"Forbidden":          403,
+	"NotFound":           404,
+	"RequestTimeout":     408,
+	"ClientClosed":       499,
+	"InternalError":      500,
+	"ServiceUnavailable": 503,
+	"GatewayTimeout":     504,
+	"AlreadyExists":      614,
+}
+
+// TestPresetCodes the preset codes are variables, make sure they keep their values and are never reassigned in the module
+func TestPresetCodes(t *testing.T) {
+	current := map[string]bcode.Code{
+		"Unknown": bcode.Unknown, "OK": bcode.OK, "InvalidArgument": bcode.InvalidArgument,
+		"Unauthorized": bcode.Unauthorized, "Forbidden": bcode.Forbidden, "NotFound": bcode.NotFound,
+		"RequestTimeout": bcode.RequestTimeout, "ClientClosed": bcode.ClientClosed, "InternalError": bcode.InternalError,
+		"ServiceUnavailable": bcode.ServiceUnavailable, "GatewayTimeout": bcode.GatewayTimeout, "AlreadyExists": bcode.AlreadyExists,
+	}
+	assert.Equal(t, len(presetCodes), len(current))
+	for name, v := range presetCodes {
+		assert.Equal(t, v, current[name].ToInt(), name)
+	}
+
+	root, err := filepath.Abs("../..")
+	assert.Nil(t, err)
+	fset := token.NewFileSet()
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") {
+			return err
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		inBCode := file.Name.Name == "bcode"
+		ast.Inspect(file, func(n ast.Node) bool {
+			var lhs []ast.Expr
+			switch tmp := n.(type) {
+			case *ast.AssignStmt:
+				if tmp.Tok != token.DEFINE {
+					lhs = tmp.Lhs
+				}
+			case *ast.IncDecStmt:
+				lhs = []ast.Expr{tmp.X}
+			}
+			for _, expr := range lhs {
+				name := ""
+				switch tmp := expr.(type) {
+				case *ast.SelectorExpr:
+					if x, ok := tmp.X.(*ast.Ident); ok && x.Name == "bcode" {
+						name = tmp.Sel.Name
+					}
+				case *ast.Ident:
+					if inBCode {
+						name = tmp.Name
+					}
+				}
+				if _, ok := presetCodes[name]; ok {
+					t.Errorf("the preset code %s is reassigned at %s", name, fset.Position(expr.Pos()))
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	assert.Nil(t, err)
+}
@@ -152,7 +152,7 @@ func (d *defaultCodeConverter) FromHTTPStatusCode(code int) Code {
 	if c, ok := d.httpStatusCodeToInternalCode[code]; ok {
 		return c
 	}
-	return defaultCode(code)
+	return New(code)
 }
 
 func (d *defaultCodeConverter) RegisterMapToGRPCCode(code Code, grpcCode codes.Code) {
@@ -1,5 +1,7 @@
 package bcode
 
+import "strconv"
+
 // Level
 // error code level, non-essential function, so it is independent and used when needed
 // for example, it can be used to judge the log or alarm level according to the error level
@@ -12,6 +14,21 @@ const (
 	LvCritical
 )
 
+// String the name of the preset levels
+func (l Level) String() string {
+	switch l {
+	case LvInfo:
+		return "info"
+	case LvNotice:
+		return "notice"
+	case LvWarning:
+		return "warning"
+	case LvCritical:
+		return "critical"
+	}
+	return "Level(" + strconv.Itoa(int(l)) + ")"
+}
+
 // codeToLevel error code to level default mapping relationship
 var codeToLevel = map[Code]Level{
 	Unknown:            LvCritical,
@@ -54,3 +71,9 @@ func ReplaceCodeLevelMapping(m map[Code]Level, defaultLevel Level) {
 	codeToLevel = m
 	defLevel = defaultLevel
 }
+
+// RegisterLevel register the level of a custom error code,
+// or overwrite the existing one.
+func RegisterLevel(code Code, level Level) {
+	codeToLevel[code] = level
+}
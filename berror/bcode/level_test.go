@@ -38,3 +38,11 @@ func TestCustomizedLevel(t *testing.T) {
 	//
 	assert.Equal(t, Lv4, bcode.RequestTimeout.GetLevel())
 }
+
+func TestRegisterLevel(t *testing.T) {
+	code := bcode.New(99998)
+	bcode.RegisterLevel(code, bcode.LvNotice)
+	assert.Equal(t, bcode.LvNotice, bcode.GetLevel(code))
+	assert.Equal(t, "notice", bcode.LvNotice.String())
+	assert.Equal(t, "Level(3)", bcode.Level(3).String())
+}
@@ -0,0 +1,55 @@
+package bcode
+
+// Retryability
+// whether the failure of a code can be recovered by retrying, non-essential function like Level.
+// for example, it can be used to decide whether a message is consumed or published again.
+type Retryability int8
+
+const (
+	// RetryUnspecified the code tells nothing, the wrapped errors decide
+	RetryUnspecified Retryability = iota
+	// Permanent retrying does not help, such as invalid parameters
+	Permanent
+	// Temporary the service is degraded for a while, retry after backing off
+	Temporary
+	// Retryable the request can be retried as is, such as timeouts
+	Retryable
+)
+
+// String the name of the retryability
+func (r Retryability) String() string {
+	switch r {
+	case Permanent:
+		return "permanent"
+	case Temporary:
+		return "temporary"
+	case Retryable:
+		return "retryable"
+	}
+	return "unspecified"
+}
+
+// codeToRetryability error code to retryability default mapping relationship
+var codeToRetryability = map[Code]Retryability{
+	InvalidArgument:    Permanent,
+	Unauthorized:       Permanent,
+	Forbidden:          Permanent,
+	NotFound:           Permanent,
+	RequestTimeout:     Retryable,
+	ClientClosed:       Permanent,
+	ServiceUnavailable: Temporary,
+	GatewayTimeout:     Retryable,
+	AlreadyExists:      Permanent,
+}
+
+// GetRetryability get error code retryability, RetryUnspecified for the unregistered ones
+func GetRetryability(code Code) Retryability {
+	return codeToRetryability[code]
+}
+
+// RegisterRetryability register the retryability of a custom error code,
+// or overwrite the existing one.
+// nb. this function is not thread-safe, call it when you initialize the program.
+func RegisterRetryability(code Code, r Retryability) {
+	codeToRetryability[code] = r
+}
@@ -0,0 +1,62 @@
+package bcatalog
+
+import (
+	"context"
+
+	"github.com/lamber92/go-brick/bconfig/bstorage"
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/lamber92/go-brick/blog/logger"
+	"github.com/spf13/cast"
+)
+
+const (
+	keyDefaultLocale = "default_locale"
+	keyMessages      = "messages"
+)
+
+// Load read the catalog under the key and replace the one of bstatus.
+// the value looks like:
+//
+//	default_locale: en
+//	messages:
+//	  en:
+//	    404: "Resource Not Found"
+//	    614: "{name} already exists"
+//	  zh-CN:
+//	    404: "资源不存在"
+//	    614: "{name}已存在"
+func Load(ctx context.Context, conf bstorage.Config, key string, namespace ...string) error {
+	v, err := conf.Load(ctx, key, namespace...)
+	if err != nil {
+		return err
+	}
+	messages := make(map[string]map[string]string)
+	for locale, m := range v.GetStringMap(keyMessages) {
+		tmp, err := cast.ToStringMapStringE(m)
+		if err != nil {
+			return berror.NewInvalidArgument(err, "invalid message catalog of locale: "+locale)
+		}
+		messages[locale] = tmp
+	}
+	bstatus.ReplaceCatalog(bstatus.NewMapCatalog(messages), v.GetString(keyDefaultLocale))
+	return nil
+}
+
+// Watch load the catalog and reload it when the config changes, such as a dynamic YAML file or an Apollo namespace.
+// a failed reload keeps the previous catalog.
+//
+// nb. it registers the OnChange callback of the config, which replaces the one registered before for YAML.
+func Watch(ctx context.Context, conf bstorage.Config, key string, namespace ...string) error {
+	if err := Load(ctx, conf, key, namespace...); err != nil {
+		return err
+	}
+	conf.RegisterOnChange(func(event string) {
+		if err := Load(context.Background(), conf, key, namespace...); err != nil {
+			logger.Infra.WithError(err).Warnw("reload message catalog fail", logger.NewField().String("event", event))
+			return
+		}
+		logger.Infra.Infow("[EVENT] message catalog reloaded", logger.NewField().String("event", event))
+	})
+	return nil
+}
@@ -0,0 +1,61 @@
+package bcatalog_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lamber92/go-brick/bconfig/bstorage/yaml"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/lamber92/go-brick/berror/bstatus/bcatalog"
+	"github.com/stretchr/testify/assert"
+)
+
+// testRoot the root dir of the yaml configs, which can be initialized only once
+var testRoot string
+
+func TestMain(m *testing.M) {
+	// work on a copy, the file is modified
+	root, err := os.MkdirTemp("", "bcatalog")
+	if err != nil {
+		panic(err)
+	}
+	if err = os.MkdirAll(filepath.Join(root, "dynamic"), 0755); err != nil {
+		panic(err)
+	}
+	testRoot = root
+	yaml.InitRootDir(root)
+	code := m.Run()
+	_ = os.RemoveAll(root)
+	os.Exit(code)
+}
+
+func TestWatch(t *testing.T) {
+	content, err := os.ReadFile("./config_test/dynamic/fat.yaml")
+	assert.Nil(t, err)
+	file := filepath.Join(testRoot, "dynamic", "fat.yaml")
+	assert.Nil(t, os.WriteFile(file, content, 0644))
+
+	conf := yaml.NewDynamic()
+	t.Cleanup(func() {
+		// the watcher can not be stopped, detach it from the catalog
+		conf.RegisterOnChange(func(string) {})
+		bstatus.ReplaceCatalog(nil, "")
+	})
+	assert.Nil(t, bcatalog.Watch(context.Background(), conf, "Catalog", "fat"))
+
+	zh := bstatus.WithLocale(context.Background(), "zh-CN")
+	assert.Equal(t, "资源不存在", bstatus.Localize(zh, bstatus.NotFound))
+	assert.Equal(t, "Resource Not Found", bstatus.Localize(context.Background(), bstatus.NotFound))
+
+	// hot reload
+	assert.Nil(t, os.WriteFile(file, []byte(strings.Replace(string(content), "资源不存在", "找不到资源", 1)), 0644))
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) && bstatus.Localize(zh, bstatus.NotFound) != "找不到资源" {
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.Equal(t, "找不到资源", bstatus.Localize(zh, bstatus.NotFound))
+}
@@ -0,0 +1,223 @@
+package bstatus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror/bcode"
+)
+
+// Catalog the localized messages keyed by code.
+// a message may contain placeholders such as '{name}', which are filled with the args of the status.
+type Catalog interface {
+	// Message return the message template of the code in the locale
+	Message(locale string, code bcode.Code) (string, bool)
+}
+
+// MapCatalog locale -> code -> message template.
+// the locales are case-insensitive, such as 'en', 'zh-CN'.
+type MapCatalog map[string]map[string]string
+
+// NewMapCatalog build a catalog from locale -> code -> message template
+func NewMapCatalog(messages map[string]map[string]string) MapCatalog {
+	out := make(MapCatalog, len(messages))
+	for locale, m := range messages {
+		locale = normalizeLocale(locale)
+		if out[locale] == nil {
+			out[locale] = make(map[string]string, len(m))
+		}
+		for code, msg := range m {
+			out[locale][code] = msg
+		}
+	}
+	return out
+}
+
+func (c MapCatalog) Message(locale string, code bcode.Code) (string, bool) {
+	m, ok := c[normalizeLocale(locale)]
+	if !ok {
+		return "", false
+	}
+	msg, ok := m[code.ToString()]
+	return msg, ok
+}
+
+type catalogHolder struct {
+	catalog       Catalog
+	defaultLocale string
+}
+
+var _catalog atomic.Value // *catalogHolder
+
+func init() {
+	_catalog.Store(&catalogHolder{})
+}
+
+// ReplaceCatalog set the catalog and the locale used when the context prefers none of the locales in it.
+// it is thread-safe, so that the catalog can be hot reloaded. nil catalog disables localization.
+func ReplaceCatalog(c Catalog, defaultLocale string) {
+	_catalog.Store(&catalogHolder{catalog: c, defaultLocale: normalizeLocale(defaultLocale)})
+}
+
+// argsStatus is implemented by the status carrying template args, see NewWithArgs
+type argsStatus interface {
+	Args() map[string]any
+}
+
+// NewWithArgs create a status whose localized message is filled with args.
+// the reason is the canonical one, which is kept in logs.
+func NewWithArgs(code bcode.Code, reason string, detail any, args map[string]any) Status {
+	return &argsDefaultStatus{
+		defaultStatus: defaultStatus{code: code, reason: reason, detail: detail},
+		args:          args,
+	}
+}
+
+type argsDefaultStatus struct {
+	defaultStatus
+	args map[string]any
+}
+
+func (s *argsDefaultStatus) Args() map[string]any {
+	return s.args
+}
+
+// Localize return the message of the status in the locales preferred by ctx (see WithLocale),
+// falling back to the default locale of the catalog, and then to the canonical reason.
+// only the generic reasons are localized: the statuses created by NewWithArgs, the empty reason,
+// and the preset reason of the code (see GetByCode). a business-specific reason is returned as it is,
+// since the catalog is keyed by code and would replace it with the message of the code.
+// use it when the reason is rendered to clients.
+func Localize(ctx context.Context, st Status) string {
+	if st == nil {
+		return ""
+	}
+	holder := _catalog.Load().(*catalogHolder)
+	if holder.catalog == nil || !localizable(st) {
+		return st.Reason()
+	}
+	var args map[string]any
+	if tmp, ok := st.(argsStatus); ok {
+		args = tmp.Args()
+	}
+	for _, locale := range candidateLocales(LocalesFromCtx(ctx), holder.defaultLocale) {
+		if msg, ok := holder.catalog.Message(locale, st.Code()); ok {
+			return fillArgs(msg, args)
+		}
+	}
+	return st.Reason()
+}
+
+// localizable report whether the reason of st is a generic one, which can be replaced by the catalog
+func localizable(st Status) bool {
+	if _, ok := st.(argsStatus); ok {
+		return true
+	}
+	reason := st.Reason()
+	if len(reason) == 0 {
+		return true
+	}
+	preset := GetByCode(st.Code())
+	return preset.Code().ToInt() == st.Code().ToInt() && preset.Reason() == reason
+}
+
+// candidateLocales expand the preferred locales with their base languages, such as 'zh-cn' -> 'zh'
+func candidateLocales(preferred []string, defaultLocale string) []string {
+	out := make([]string, 0, len(preferred)*2+1)
+	for _, l := range preferred {
+		l = normalizeLocale(l)
+		out = append(out, l)
+		if idx := strings.IndexByte(l, '-'); idx > 0 {
+			out = append(out, l[:idx])
+		}
+	}
+	if len(defaultLocale) > 0 {
+		out = append(out, defaultLocale)
+	}
+	return out
+}
+
+// fillArgs replace the placeholders such as '{name}'
+func fillArgs(msg string, args map[string]any) string {
+	if len(args) == 0 || !strings.Contains(msg, "{") {
+		return msg
+	}
+	pairs := make([]string, 0, len(args)*2)
+	for k, v := range args {
+		pairs = append(pairs, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// =======================================
+// -------- Locale in the Context --------
+// =======================================
+
+// WithLocale set the preferred locales into ctx, the first is the most preferred.
+// the locales are stored into bcontext.Context directly, a plain context.Context gets a derived one.
+func WithLocale(ctx context.Context, locales ...string) context.Context {
+	switch tmp := ctx.(type) {
+	case bcontext.Context:
+		return tmp.Set(bcontext.Locale, locales)
+	default:
+		return context.WithValue(ctx, bcontext.Locale, locales)
+	}
+}
+
+// LocalesFromCtx get the preferred locales from ctx
+func LocalesFromCtx(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	switch tmp := ctx.Value(bcontext.Locale).(type) {
+	case []string:
+		return tmp
+	case string:
+		return []string{tmp}
+	}
+	return nil
+}
+
+// ParseAcceptLanguage parse the Accept-Language header into the locales in order of preference,
+// such as 'zh-CN,zh;q=0.9,en;q=0.8' -> [zh-CN zh en]
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+	list := make([]weighted, 0)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		locale := strings.TrimSpace(fields[0])
+		if len(locale) == 0 || locale == "*" {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if v, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			list = append(list, weighted{locale: locale, q: q})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].q > list[j].q })
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		out = append(out, v.locale)
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package bstatus_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalize(t *testing.T) {
+	defer bstatus.ReplaceCatalog(nil, "")
+	st := bstatus.NewWithArgs(bcode.AlreadyExists, "user already exists", nil, map[string]any{"name": "Tom"})
+	ctx := bstatus.WithLocale(bcontext.New(), "zh-CN")
+
+	// disabled by default
+	assert.Equal(t, "user already exists", bstatus.Localize(ctx, st))
+
+	bstatus.ReplaceCatalog(bstatus.NewMapCatalog(map[string]map[string]string{
+		"en":    {"404": "Resource Not Found", "614": "{name} already exists"},
+		"zh":    {"404": "资源不存在"},
+		"zh-CN": {"614": "{name}已存在"},
+	}), "en")
+	assert.Equal(t, "Tom已存在", bstatus.Localize(ctx, st))
+	// the canonical reason is kept
+	assert.Equal(t, "user already exists", st.Reason())
+	// base language
+	assert.Equal(t, "资源不存在", bstatus.Localize(ctx, bstatus.NotFound))
+	// default locale
+	assert.Equal(t, "Tom already exists", bstatus.Localize(context.Background(), st))
+	// a business-specific reason is kept
+	assert.Equal(t, "order not found", bstatus.Localize(ctx, bstatus.New(bcode.NotFound, "order not found", nil)))
+	assert.Equal(t, "资源不存在", bstatus.Localize(ctx, bstatus.New(bcode.NotFound, "", nil)))
+	// no message at all
+	assert.Equal(t, "Internal Server Error", bstatus.Localize(ctx, bstatus.InternalError))
+
+	// a plain context
+	std := bstatus.WithLocale(context.Background(), bstatus.ParseAcceptLanguage("fr;q=0.5, zh-cn")...)
+	assert.Equal(t, []string{"zh-cn", "fr"}, bstatus.LocalesFromCtx(std))
+	assert.Equal(t, "Tom已存在", bstatus.Localize(std, st))
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	assert.Equal(t, []string{"zh-CN", "zh", "en"}, bstatus.ParseAcceptLanguage("zh-CN,zh;q=0.9,en;q=0.8"))
+	assert.Equal(t, []string{"en"}, bstatus.ParseAcceptLanguage("*, en;q=0.1, de;q=0"))
+	assert.Equal(t, []string{}, bstatus.ParseAcceptLanguage(""))
+}
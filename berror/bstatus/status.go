@@ -57,6 +57,11 @@ func (c *defaultStatus) Detail() any {
 	return c.detail
 }
 
+// Error make the preset statuses usable as the targets of errors.Is, such as 'errors.Is(err, bstatus.NotFound)'
+func (c *defaultStatus) Error() string {
+	return c.String()
+}
+
 func (c *defaultStatus) String() string {
 	if c.detail != nil {
 		return fmt.Sprintf("[%d]:%s. (detail: %v)", c.code, c.reason, c.detail)
@@ -0,0 +1,54 @@
+package berror
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// the formats of WriteCatalog
+const (
+	CatalogJSON     = "json"
+	CatalogMarkdown = "markdown"
+)
+
+// WriteCatalog export the error codes registered by Define (see Definitions) as JSON or Markdown, for API documentation.
+// only the codes of the packages linked into the program are exported, import the packages defining the codes first.
+// format is CatalogJSON or CatalogMarkdown ('md' for short).
+func WriteCatalog(w io.Writer, format string) error {
+	list := Definitions()
+	switch format {
+	case CatalogJSON:
+		return list.WriteJSON(w)
+	case CatalogMarkdown, "md":
+		return list.WriteMarkdown(w)
+	}
+	return NewInvalidArgument(nil, fmt.Sprintf("unknown catalog format '%s'", format))
+}
+
+// RunCatalog the command exporting the catalog, the flags are parsed from args (without the program name):
+//
+//	-format json|markdown   the output format, json by default
+//	-o file                 the output file, stdout by default
+//
+// a service wraps it with a main package that imports its own packages, see berror/cmd/catalog.
+func RunCatalog(args []string) error {
+	fs := flag.NewFlagSet("catalog", flag.ContinueOnError)
+	format := fs.String("format", CatalogJSON, "output format: json or markdown")
+	output := fs.String("o", "", "output file, defaults to stdout")
+	if err := fs.Parse(args); err != nil {
+		return NewInvalidArgument(err, "parse catalog flags fail")
+	}
+
+	var w io.Writer = os.Stdout
+	if len(*output) > 0 {
+		f, err := os.Create(*output)
+		if err != nil {
+			return Convert(err, "create catalog file fail")
+		}
+		defer f.Close()
+		w = f
+	}
+	return WriteCatalog(w, *format)
+}
@@ -0,0 +1,21 @@
+// Command catalog export the error codes defined by berror.Define as JSON or Markdown for API documentation.
+//
+// usage: go run ./berror/cmd/catalog -format markdown -o errors.md
+//
+// only the codes of the packages imported here are exported.
+// to export the codes of a service, write the same main package importing the packages of the service.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/lamber92/go-brick/berror"
+	_ "github.com/lamber92/go-brick/bmq/brabbitmq/consumer"
+)
+
+func main() {
+	if err := berror.RunCatalog(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
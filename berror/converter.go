@@ -3,11 +3,8 @@ package berror
 import (
 	"sync"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/lamber92/go-brick/berror/bcode"
 	"github.com/lamber92/go-brick/berror/bstatus"
-	"google.golang.org/grpc/status"
-	"gorm.io/gorm"
 )
 
 var (
@@ -20,7 +17,8 @@ func ReplaceConverter(c Converter) {
 	defConv = c
 }
 
-// RegisterConvHook register hook function.
+// RegisterConvHook register hook function, which takes over all the errors that are not Error.
+// it can be registered only once, use RegisterConvRuleBefore to customize a part of the conversion instead.
 // nb. if you need this Hook, call it when you initialize the program.
 func RegisterConvHook(hook HookFunc) {
 	defConv.Hook(hook)
@@ -59,6 +57,7 @@ func newDefaultConverter() *defaultConverter {
 }
 
 // Convert when all error types are encountered, they are automatically wrapped as Error types.
+// the errors that are not Error are tried with the hook, the customized mapping and then the rules in order.
 // if you don't want to deal with the Error type, use the IgnoreWrapError option.
 func (dc *defaultConverter) Convert(err error, reason string, detail any, options ...ConvOption) error {
 	if err == nil {
@@ -80,19 +79,12 @@ func (dc *defaultConverter) Convert(err error, reason string, detail any, option
 		return dc.hook(err, reason, detail, options...)
 	}
 
-	// TODO: Transfer the following business error codes to a custom error code mapping table
 	if tmp, ok := defCustomizedMapping.Load(err); ok {
 		return tmp.(error)
 	}
-	// check gorm/redis error
-	switch err {
-	case gorm.ErrRecordNotFound, redis.Nil:
-		return NewWithSkip(err, bstatus.New(bcode.NotFound, reason, detail), 1)
-	}
-	// check it's grpc error or not
-	if gerr, ok := status.FromError(err); ok && gerr != nil {
-		code := bcode.FromGRPCCode(gerr.Code())
-		return NewWithSkip(err, bstatus.New(code, reason, detail), 1)
+	// try the rules in order, see RegisterConvRule
+	if code, cause, ok := lookupConvRules(err); ok {
+		return NewWithSkip(cause, bstatus.New(code, reason, detail), 1)
 	}
 	// unknown error
 	return NewWithSkip(err, bstatus.New(bcode.Unknown, reason, detail), 1)
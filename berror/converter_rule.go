@@ -0,0 +1,252 @@
+package berror
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/lamber92/go-brick/berror/bcode"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// ConvRule one ring of the chain consulted by Convert, the rules are tried in order until one of them handles err.
+// returns the code of the converted Error and the cause it wraps (usually err itself),
+// or 'false' if the rule does not handle err.
+type ConvRule func(err error) (code bcode.Code, cause error, ok bool)
+
+// the names of the built-in rules, in the order they are tried
+const (
+	ConvRuleGorm    = "gorm"
+	ConvRuleRedis   = "redis"
+	ConvRuleSQL     = "sql"
+	ConvRuleContext = "context"
+	ConvRuleNet     = "net"
+	ConvRuleAMQP    = "amqp"
+	ConvRuleOS      = "os"
+	ConvRuleGRPC    = "grpc"
+)
+
+type namedConvRule struct {
+	name string
+	rule ConvRule
+}
+
+var (
+	convRules = []namedConvRule{
+		{ConvRuleGorm, ConvRuleIs(bcode.NotFound, gorm.ErrRecordNotFound)},
+		{ConvRuleRedis, ConvRuleIs(bcode.NotFound, redis.Nil)},
+		{ConvRuleSQL, ConvRuleIs(bcode.NotFound, sql.ErrNoRows)},
+		{ConvRuleContext, convContext},
+		{ConvRuleNet, convNet},
+		{ConvRuleAMQP, convAMQP},
+		{ConvRuleOS, convOS},
+		{ConvRuleGRPC, convGRPC},
+	}
+	convRulesMu sync.RWMutex
+)
+
+// RegisterConvRule append the rule to the end of the chain, after the built-ins
+func RegisterConvRule(name string, rule ConvRule) error {
+	convRulesMu.Lock()
+	defer convRulesMu.Unlock()
+	return insertConvRule(len(convRules), name, rule)
+}
+
+// RegisterConvRuleBefore insert the rule before the rule named target, such as ConvRuleGorm.
+// an empty target inserts it to the head of the chain, before all the built-ins.
+func RegisterConvRuleBefore(target, name string, rule ConvRule) error {
+	convRulesMu.Lock()
+	defer convRulesMu.Unlock()
+	if len(target) == 0 {
+		return insertConvRule(0, name, rule)
+	}
+	idx := indexConvRule(target)
+	if idx < 0 {
+		return NewNotFound(nil, fmt.Sprintf("converter rule '%s' not found", target))
+	}
+	return insertConvRule(idx, name, rule)
+}
+
+// RegisterConvRuleAfter insert the rule after the rule named target, such as ConvRuleGRPC
+func RegisterConvRuleAfter(target, name string, rule ConvRule) error {
+	convRulesMu.Lock()
+	defer convRulesMu.Unlock()
+	idx := indexConvRule(target)
+	if idx < 0 {
+		return NewNotFound(nil, fmt.Sprintf("converter rule '%s' not found", target))
+	}
+	return insertConvRule(idx+1, name, rule)
+}
+
+// UnregisterConvRule remove the rule named name from the chain, the built-ins can be removed as well.
+// returns NotFound if there is no such rule.
+func UnregisterConvRule(name string) error {
+	convRulesMu.Lock()
+	defer convRulesMu.Unlock()
+	idx := indexConvRule(name)
+	if idx < 0 {
+		return NewNotFound(nil, fmt.Sprintf("converter rule '%s' not found", name))
+	}
+	// copy on write, so that the running lookups are not affected
+	tmp := make([]namedConvRule, 0, len(convRules)-1)
+	tmp = append(tmp, convRules[:idx]...)
+	tmp = append(tmp, convRules[idx+1:]...)
+	convRules = tmp
+	return nil
+}
+
+// ConvRules the names of the rules in the chain, in order
+func ConvRules() []string {
+	convRulesMu.RLock()
+	defer convRulesMu.RUnlock()
+	out := make([]string, 0, len(convRules))
+	for _, v := range convRules {
+		out = append(out, v.name)
+	}
+	return out
+}
+
+func indexConvRule(name string) int {
+	for i, v := range convRules {
+		if v.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func insertConvRule(idx int, name string, rule ConvRule) error {
+	if rule == nil {
+		return NewInvalidArgument(nil, "converter rule is nil")
+	}
+	if indexConvRule(name) >= 0 {
+		return NewAlreadyExists(nil, fmt.Sprintf("converter rule '%s' already exists", name))
+	}
+	// copy on write, so that the running lookups are not affected
+	tmp := make([]namedConvRule, 0, len(convRules)+1)
+	tmp = append(tmp, convRules[:idx]...)
+	tmp = append(tmp, namedConvRule{name: name, rule: rule})
+	tmp = append(tmp, convRules[idx:]...)
+	convRules = tmp
+	return nil
+}
+
+// lookupConvRules try the rules in order
+func lookupConvRules(err error) (bcode.Code, error, bool) {
+	convRulesMu.RLock()
+	rules := convRules
+	convRulesMu.RUnlock()
+	for _, v := range rules {
+		if code, cause, ok := v.rule(err); ok {
+			return code, cause, true
+		}
+	}
+	return nil, nil, false
+}
+
+// ConvRuleIs build a rule mapping the errors that match one of the targets by errors.Is to the code
+func ConvRuleIs(code bcode.Code, targets ...error) ConvRule {
+	return func(err error) (bcode.Code, error, bool) {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return code, err, true
+			}
+		}
+		return nil, nil, false
+	}
+}
+
+// ConvRuleAs build a rule mapping the errors that match the type T by errors.As to the code
+func ConvRuleAs[T error](code bcode.Code) ConvRule {
+	return func(err error) (bcode.Code, error, bool) {
+		var target T
+		if errors.As(err, &target) {
+			return code, err, true
+		}
+		return nil, nil, false
+	}
+}
+
+// ConvRuleFunc build a rule mapping the errors that match the predicate to the code
+func ConvRuleFunc(code bcode.Code, match func(err error) bool) ConvRule {
+	return func(err error) (bcode.Code, error, bool) {
+		if match(err) {
+			return code, err, true
+		}
+		return nil, nil, false
+	}
+}
+
+// =======================================
+// ---------- Built-in Adapters ----------
+// =======================================
+
+// convContext the deadline is exceeded -> RequestTimeout, the context is canceled -> ClientClosed
+func convContext(err error) (bcode.Code, error, bool) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return bcode.RequestTimeout, err, true
+	case errors.Is(err, context.Canceled):
+		return bcode.ClientClosed, err, true
+	}
+	return nil, nil, false
+}
+
+// convNet the timeouts of the network -> GatewayTimeout
+func convNet(err error) (bcode.Code, error, bool) {
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return bcode.GatewayTimeout, err, true
+	}
+	return nil, nil, false
+}
+
+// convAMQP map the reply codes of the AMQP errors
+func convAMQP(err error) (bcode.Code, error, bool) {
+	var ae *amqp.Error
+	if !errors.As(err, &ae) {
+		return nil, nil, false
+	}
+	switch ae.Code {
+	case amqp.NotFound:
+		return bcode.NotFound, err, true
+	case amqp.AccessRefused, amqp.ResourceLocked:
+		return bcode.Forbidden, err, true
+	case amqp.PreconditionFailed, amqp.ContentTooLarge:
+		return bcode.InvalidArgument, err, true
+	case amqp.NoRoute, amqp.NoConsumers, amqp.ConnectionForced, amqp.ChannelError, amqp.ResourceError:
+		return bcode.ServiceUnavailable, err, true
+	}
+	if ae.Recover {
+		return bcode.ServiceUnavailable, err, true
+	}
+	return bcode.InternalError, err, true
+}
+
+// convOS the errors of the file system, such as *fs.PathError
+func convOS(err error) (bcode.Code, error, bool) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return bcode.NotFound, err, true
+	case errors.Is(err, fs.ErrPermission):
+		return bcode.Forbidden, err, true
+	case errors.Is(err, fs.ErrExist):
+		return bcode.AlreadyExists, err, true
+	}
+	return nil, nil, false
+}
+
+// convGRPC keep the business code attached by ToGRPCStatus if there is one
+func convGRPC(err error) (bcode.Code, error, bool) {
+	if gerr, ok := status.FromError(err); ok && gerr != nil {
+		return statusFromGRPC(gerr).Code(), FromGRPCStatus(gerr), true
+	}
+	return nil, nil, false
+}
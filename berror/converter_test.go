@@ -1,12 +1,24 @@
 package berror_test
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/lamber92/go-brick/berror"
 	"github.com/lamber92/go-brick/berror/bcode"
 	"github.com/lamber92/go-brick/berror/bstatus"
+	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 	"gorm.io/gorm"
 )
 
@@ -51,7 +63,82 @@ func TestDefaultConverter_Convert4(t *testing.T) {
 	assert.Equal(t, bcode.Unknown, err4.(berror.Error).Status().Code())
 }
 
+func TestDefaultConverter_GRPC(t *testing.T) {
+	// the business code attached by berror.ToGRPCStatus is kept
+	client := newTestGRPCClient(t, berror.New(bstatus.New(bcode.New(testGRPCCode), "custom reason", nil)))
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, codes.Unknown, status.Code(err))
+	err = berror.Convert(err, "call health check fail")
+	assert.Equal(t, true, berror.IsCode(err, bcode.New(testGRPCCode)))
+	assert.Equal(t, true, berror.IsRemote(err))
+}
+
+type testTimeoutError struct{}
+
+func (testTimeoutError) Error() string   { return "i/o timeout" }
+func (testTimeoutError) Timeout() bool   { return true }
+func (testTimeoutError) Temporary() bool { return true }
+
+func TestDefaultConverter_Rules(t *testing.T) {
+	_, notExist := os.Open(filepath.Join(t.TempDir(), "not-exist"))
+	cases := []struct {
+		err  error
+		code bcode.Code
+	}{
+		{fmt.Errorf("query: %w", sql.ErrNoRows), bcode.NotFound},
+		{context.DeadlineExceeded, bcode.RequestTimeout},
+		{context.Canceled, bcode.ClientClosed},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: testTimeoutError{}}, bcode.GatewayTimeout},
+		{&amqp.Error{Code: amqp.NotFound, Reason: "no queue"}, bcode.NotFound},
+		{&amqp.Error{Code: amqp.AccessRefused}, bcode.Forbidden},
+		{&amqp.Error{Code: amqp.FrameError, Recover: true}, bcode.ServiceUnavailable},
+		{&amqp.Error{Code: amqp.FrameError}, bcode.InternalError},
+		{notExist, bcode.NotFound},
+		{fs.ErrPermission, bcode.Forbidden},
+		{errors.New("unknown"), bcode.Unknown},
+	}
+	for i, v := range cases {
+		err := berror.Convert(v.err, "convert")
+		assert.Equal(t, v.code, berror.CodeOf(err), i)
+		assert.ErrorIs(t, err, v.err, i)
+	}
+}
+
+func TestRegisterConvRule(t *testing.T) {
+	t.Cleanup(func() {
+		for _, name := range []string{"test-locked", "test-no-rows", "test-path"} {
+			_ = berror.UnregisterConvRule(name)
+		}
+	})
+	errLocked := errors.New("locked")
+	assert.Nil(t, berror.RegisterConvRule("test-locked", berror.ConvRuleIs(bcode.Forbidden, errLocked)))
+	assert.NotNil(t, berror.RegisterConvRule("test-locked", berror.ConvRuleIs(bcode.Forbidden, errLocked)))
+	assert.Equal(t, bcode.Forbidden, berror.CodeOf(berror.Convert(errLocked, "locked")))
+
+	// before the built-ins
+	assert.Nil(t, berror.RegisterConvRuleBefore(berror.ConvRuleSQL, "test-no-rows",
+		berror.ConvRuleFunc(bcode.AlreadyExists, func(err error) bool { return errors.Is(err, sql.ErrNoRows) })))
+	assert.Equal(t, bcode.AlreadyExists, berror.CodeOf(berror.Convert(sql.ErrNoRows, "no rows")))
+	// after the built-ins, the built-in wins
+	assert.Nil(t, berror.RegisterConvRuleAfter(berror.ConvRuleOS, "test-path",
+		berror.ConvRuleAs[*fs.PathError](bcode.InternalError)))
+	_, notExist := os.Open(filepath.Join(t.TempDir(), "not-exist"))
+	assert.Equal(t, bcode.NotFound, berror.CodeOf(berror.Convert(notExist, "open")))
+	assert.NotNil(t, berror.RegisterConvRuleAfter("not-exist", "test-x", berror.ConvRuleIs(bcode.OK)))
+
+	rules := berror.ConvRules()
+	assert.Equal(t, "test-no-rows", rules[2])
+	assert.Equal(t, "test-path", rules[8])
+	assert.Equal(t, "test-locked", rules[len(rules)-1])
+
+	assert.Nil(t, berror.UnregisterConvRule("test-no-rows"))
+	assert.NotNil(t, berror.UnregisterConvRule("test-no-rows"))
+	assert.Equal(t, bcode.NotFound, berror.CodeOf(berror.Convert(sql.ErrNoRows, "no rows")))
+	assert.Equal(t, len(rules)-1, len(berror.ConvRules()))
+}
+
 func TestDefaultConverter_Hook(t *testing.T) {
+	t.Cleanup(berror.ResetConverter)
 	berror.RegisterConvHook(func(err error, reason string, detail any, options ...berror.ConvOption) error {
 		switch err {
 		case gorm.ErrRecordNotFound:
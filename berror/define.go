@@ -0,0 +1,151 @@
+package berror
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/lamber92/go-brick/internal/json"
+	"google.golang.org/grpc/codes"
+)
+
+// Definition an error code registered by Define
+type Definition struct {
+	Code   int    `json:"code"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	Level  string `json:"level"`
+	GRPC   string `json:"grpc"`
+	HTTP   int    `json:"http"`
+}
+
+// DefinitionList definitions ordered by code
+type DefinitionList []*Definition
+
+var (
+	definitions   = make(map[int]*Definition)
+	definedNames  = make(map[string]int)
+	definitionsMu sync.Mutex
+)
+
+func init() {
+	presets := []struct {
+		name   string
+		status bstatus.Status
+	}{
+		{"unknown", bstatus.Unknown},
+		{"ok", bstatus.OK},
+		{"invalid_argument", bstatus.InvalidArgument},
+		{"unauthorized", bstatus.Unauthorized},
+		{"forbidden", bstatus.Forbidden},
+		{"not_found", bstatus.NotFound},
+		{"request_timeout", bstatus.RequestTimeout},
+		{"client_closed", bstatus.ClientClosed},
+		{"internal_error", bstatus.InternalError},
+		{"service_unavailable", bstatus.ServiceUnavailable},
+		{"gateway_timeout", bstatus.GatewayTimeout},
+		{"already_exists", bstatus.AlreadyExists},
+	}
+	for _, v := range presets {
+		code := v.status.Code()
+		definitions[code.ToInt()] = newDefinition(code, v.name, v.status.Reason(),
+			bcode.GetLevel(code), bcode.ToGRPCCode(code), bcode.ToHTTPStatusCode(code))
+		definedNames[v.name] = code.ToInt()
+	}
+}
+
+func newDefinition(code bcode.Code, name, reason string, level bcode.Level, grpc codes.Code, http int) *Definition {
+	return &Definition{
+		Code:   code.ToInt(),
+		Name:   name,
+		Reason: reason,
+		Level:  level.String(),
+		GRPC:   grpc.String(),
+		HTTP:   http,
+	}
+}
+
+// Define register a code everywhere at once: the status of bstatus.GetByCode, the level,
+// and the mappings to the gRPC code and the http-status-code.
+// the mappings from the gRPC code and the http-status-code are kept, so that they still map to the preset codes.
+// it panics if the code or the name is already defined, including the codes registered by bstatus,
+// or if http is not a valid http-status-code (100-599).
+// returns the status of the code, which can be passed to New.
+//
+// nb. this function is not thread-safe with the mappings of bcode and bstatus,
+// call it when you initialize the program, such as in a package-level var declaration.
+func Define(code bcode.Code, name, reason string, level bcode.Level, grpc codes.Code, http int) bstatus.Status {
+	definitionsMu.Lock()
+	defer definitionsMu.Unlock()
+
+	if exist, ok := definitions[code.ToInt()]; ok {
+		panic(NewAlreadyExists(nil, fmt.Sprintf("error code %d is already defined as '%s'", code.ToInt(), exist.Name)))
+	}
+	if st := bstatus.GetByCode(code); st.Code().ToInt() == code.ToInt() {
+		panic(NewAlreadyExists(nil, fmt.Sprintf("error code %d is already registered with reason '%s'", code.ToInt(), st.Reason())))
+	}
+	if exist, ok := definedNames[name]; ok {
+		panic(NewAlreadyExists(nil, fmt.Sprintf("error name '%s' is already defined by code %d", name, exist)))
+	}
+	if !validHTTPStatus(http) {
+		panic(NewInvalidArgument(nil, fmt.Sprintf("http-status-code %d of error code %d is out of 100-599", http, code.ToInt())))
+	}
+
+	st := bstatus.New(code, reason, nil)
+	bstatus.RegisterMapFromCode(code, st)
+	bcode.RegisterLevel(code, level)
+	bcode.RegisterMapToGRPCCode(code, grpc)
+	bcode.RegisterMapToHTTPStatusCode(code, http)
+
+	definitions[code.ToInt()] = newDefinition(code, name, reason, level, grpc, http)
+	definedNames[name] = code.ToInt()
+	return st
+}
+
+// Definitions return copies of all the definitions, including the preset codes
+func Definitions() DefinitionList {
+	definitionsMu.Lock()
+	defer definitionsMu.Unlock()
+	out := make(DefinitionList, 0, len(definitions))
+	for _, v := range definitions {
+		tmp := *v
+		out = append(out, &tmp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out
+}
+
+// WriteJSON export the definitions as a JSON array
+func (l DefinitionList) WriteJSON(w io.Writer) error {
+	raw, err := json.MarshalIndent(l, 2)
+	if err != nil {
+		return NewInternalError(err, "marshal error definitions fail")
+	}
+	if _, err = w.Write(append(raw, '\n')); err != nil {
+		return Convert(err, "write error definitions fail")
+	}
+	return nil
+}
+
+// WriteMarkdown export the definitions as a Markdown table
+func (l DefinitionList) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("| Code | Name | Reason | Level | gRPC | HTTP |\n")
+	b.WriteString("| ---: | ---- | ------ | ----- | ---- | ---: |\n")
+	for _, v := range l {
+		_, _ = fmt.Fprintf(&b, "| %d | %s | %s | %s | %s | %d |\n",
+			v.Code, escapeMarkdown(v.Name), escapeMarkdown(v.Reason), v.Level, v.GRPC, v.HTTP)
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return Convert(err, "write error definitions fail")
+	}
+	return nil
+}
+
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
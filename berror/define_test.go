@@ -0,0 +1,81 @@
+package berror_test
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+// defined once at the package level, as Define is meant to be called, so that the test can be run repeatedly
+var (
+	testOrderLockedCode = bcode.New(9010001)
+	testOrderLocked     = berror.Define(testOrderLockedCode, "test_order_locked", "order is locked",
+		bcode.LvWarning, codes.FailedPrecondition, http.StatusConflict)
+)
+
+func TestDefine(t *testing.T) {
+	code, st := testOrderLockedCode, testOrderLocked
+	assert.Equal(t, "order is locked", st.Reason())
+	assert.Equal(t, st, bstatus.GetByCode(code))
+	assert.Equal(t, bcode.LvWarning, bcode.GetLevel(code))
+	assert.Equal(t, codes.FailedPrecondition, bcode.ToGRPCCode(code))
+	assert.Equal(t, http.StatusConflict, bcode.ToHTTPStatusCode(code))
+	// the reverse mappings are kept
+	assert.Equal(t, bcode.NotFound, bcode.FromHTTPStatusCode(http.StatusNotFound))
+
+	// collisions
+	assert.Panics(t, func() { berror.Define(code, "test_other", "other", bcode.LvInfo, codes.Unknown, 500) })
+	assert.Panics(t, func() {
+		berror.Define(bcode.New(9010002), "test_order_locked", "other", bcode.LvInfo, codes.Unknown, 500)
+	})
+	assert.Panics(t, func() { berror.Define(bcode.NotFound, "test_not_found", "other", bcode.LvInfo, codes.Unknown, 500) })
+	legacy := bcode.New(9010003)
+	bstatus.RegisterMapFromCode(legacy, bstatus.New(legacy, "legacy", nil))
+	assert.Panics(t, func() { berror.Define(legacy, "test_legacy", "legacy", bcode.LvInfo, codes.Unknown, 500) })
+	// invalid http-status-code
+	assert.Panics(t, func() { berror.Define(bcode.New(9010004), "test_http", "http", bcode.LvInfo, codes.Unknown, 5010001) })
+	assert.Panics(t, func() { berror.Define(bcode.New(9010005), "test_http_0", "http", bcode.LvInfo, codes.Unknown, 0) })
+
+	var found bool
+	for _, v := range berror.Definitions() {
+		if v.Code == 9010001 {
+			found = true
+			assert.Equal(t, "test_order_locked", v.Name)
+			assert.Equal(t, "warning", v.Level)
+			assert.Equal(t, "FailedPrecondition", v.GRPC)
+		}
+	}
+	assert.Equal(t, true, found)
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, berror.Definitions().WriteMarkdown(buf))
+	assert.Contains(t, buf.String(), "| 9010001 | test_order_locked | order is locked | warning | FailedPrecondition | 409 |")
+	buf.Reset()
+	assert.Nil(t, berror.Definitions().WriteJSON(buf))
+	assert.Contains(t, buf.String(), `"name": "test_order_locked"`)
+}
+
+func TestWriteCatalog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.Nil(t, berror.WriteCatalog(buf, berror.CatalogMarkdown))
+	assert.Contains(t, buf.String(), "| 404 | not_found |")
+	buf.Reset()
+	assert.Nil(t, berror.WriteCatalog(buf, berror.CatalogJSON))
+	assert.Contains(t, buf.String(), `"code": 404`)
+	assert.Equal(t, true, berror.IsCode(berror.WriteCatalog(buf, "xml"), bcode.InvalidArgument))
+
+	out := filepath.Join(t.TempDir(), "errors.md")
+	assert.Nil(t, berror.RunCatalog([]string{"-format", "md", "-o", out}))
+	raw, err := os.ReadFile(out)
+	assert.Nil(t, err)
+	assert.Contains(t, string(raw), "| 404 | not_found |")
+	assert.Equal(t, true, berror.IsCode(berror.RunCatalog([]string{"-unknown"}), bcode.InvalidArgument))
+}
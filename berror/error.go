@@ -1,8 +1,6 @@
 package berror
 
 import (
-	"errors"
-
 	jsoniter "github.com/json-iterator/go"
 	"github.com/lamber92/go-brick/berror/bcode"
 	"github.com/lamber92/go-brick/berror/bstatus"
@@ -18,6 +16,7 @@ type defaultError struct {
 	err    error            // original error
 	status bstatus.Status   // business information
 	stack  bstack.StackList // stack information when this object(*defaultError) was created
+	retry  retryMark        // explicit retryability, see MarkRetryable
 }
 
 // New create and return an error containing a code and reason.
@@ -28,6 +27,8 @@ type defaultError struct {
 //
 // nb 2. if @err type is *defaultError,
 // the @err stack will be inherited.
+//
+// nb 3. the stack is taken according to the StackPolicy.
 func New(status bstatus.Status, err ...error) Error {
 	e := &defaultError{status: status}
 	// check original err and try to inherit err-stack
@@ -38,13 +39,22 @@ func New(status bstatus.Status, err ...error) Error {
 		}
 	}
 	// generate new stack info
-	if e.stack == nil {
-		e.stack = bstack.TakeStack(1, bstack.StacktraceMax)
+	if e.stack.IsEmpty() {
+		e.stack = takeStack(1, statusCode(status))
 	}
 	return e
 }
 
+// statusCode the code of the status, bcode.Unknown if it is nil
+func statusCode(status bstatus.Status) bcode.Code {
+	if status == nil {
+		return bcode.Unknown
+	}
+	return status.Code()
+}
+
 // NewWithSkip create and return an error containing the stack trace.
+// the stack is taken according to the StackPolicy.
 // @offset: offset stack depth
 //
 // nb. if @err type is *defaultError,
@@ -62,19 +72,19 @@ func NewWithSkip(err error, status bstatus.Status, skip int) Error {
 		}
 	}
 	// generate new stack info
-	if e.stack == nil {
-		e.stack = bstack.TakeStack(skip+1, bstack.StacktraceMax)
+	if e.stack.IsEmpty() {
+		e.stack = takeStack(skip+1, statusCode(status))
 	}
 	return e
 }
 
-// Error output error information in string format
+// Error output error information in string format, rendered by the ErrorFunc (see ReplaceErrorFunc).
+// the formats of fmt.Formatter are also available, see Format.
 func (d *defaultError) Error() string {
 	if d == nil {
 		return ""
 	}
-	str, _ := jsonStdIter.MarshalToString(d.format())
-	return str
+	return errorFunc(d)
 }
 
 // Status get main status
@@ -87,7 +97,7 @@ func (d *defaultError) Status() bstatus.Status {
 
 // Stack list the error tracking information that has been collected
 func (d *defaultError) Stack() bstack.StackList {
-	if d == nil || d.stack == nil || len(d.stack) == 0 {
+	if d == nil || d.stack.IsEmpty() {
 		return bstack.StackList{}
 	}
 	return d.stack
@@ -109,6 +119,24 @@ func (d *defaultError) Unwrap() error {
 	return d.err
 }
 
+// Is report whether the code of the error is the one of target, so that errors.Is matches by code anywhere in the chain.
+// target can be an Error (such as a sentinel error), a bstatus.Status (such as bstatus.NotFound)
+// or a bcode.Code that implements error (such as bcode.NotFound).
+func (d *defaultError) Is(target error) bool {
+	if d == nil || d.status == nil {
+		return false
+	}
+	switch tmp := target.(type) {
+	case Error:
+		return tmp.Status() != nil && d.status.Code().Is(tmp.Status().Code())
+	case bstatus.Status:
+		return d.status.Code().Is(tmp.Code())
+	case bcode.Code:
+		return d.status.Code().Is(tmp)
+	}
+	return false
+}
+
 type summary struct {
 	Code   bcode.Code `json:"code"`
 	Reason string     `json:"reason"`
@@ -116,7 +144,8 @@ type summary struct {
 	Next   any        `json:"next"`
 }
 
-func (d *defaultError) format() *summary {
+// format the details are redacted if redact is true, see Redact
+func (d *defaultError) format(redact bool) *summary {
 	if d == nil || d.status == nil {
 		return nil
 	}
@@ -125,12 +154,17 @@ func (d *defaultError) format() *summary {
 		Reason: d.status.Reason(),
 		Detail: d.status.Detail(),
 	}
+	if redact {
+		sum.Detail = Redact(sum.Detail)
+	}
 	if d.err == nil {
 		sum.Next = nil
 	} else {
 		switch next := d.err.(type) {
 		case *defaultError:
-			sum.Next = next.format()
+			sum.Next = next.format(redact)
+		case *joinError:
+			sum.Next = next.format(redact)
 		default:
 			sum.Next = next.Error()
 		}
@@ -139,14 +173,20 @@ func (d *defaultError) format() *summary {
 }
 
 // MarshalLogObject zapcore.ObjectMarshaler impl
+// the detail is redacted in the environments that can not be debugged, see Redact.
 func (d *defaultError) MarshalLogObject(enc zapcore.ObjectEncoder) (err error) {
 	// code/reason
 	status := d.status
 	enc.AddInt("code", status.Code().ToInt())
 	enc.AddString("reason", status.Reason())
-	// detail
+	if d.retry != retryUnmarked {
+		enc.AddBool("retryable", d.retry == retryMarked)
+	}
+	// detail, which is redacted in the environments that can not be debugged
 	if status.Detail() != nil {
-		if obj, ok := status.Detail().(zapcore.ObjectMarshaler); ok {
+		if !isDebug() {
+			_ = enc.AddReflected("detail", Redact(status.Detail()))
+		} else if obj, ok := status.Detail().(zapcore.ObjectMarshaler); ok {
 			_ = enc.AddObject("detail", obj)
 		} else {
 			_ = enc.AddReflected("detail", status.Detail())
@@ -156,7 +196,11 @@ func (d *defaultError) MarshalLogObject(enc zapcore.ObjectEncoder) (err error) {
 	if d.err == nil {
 		return
 	}
-	if next, ok := d.err.(*defaultError); ok {
+	switch next := d.err.(type) {
+	case *defaultError:
+		_ = enc.AddObject("next", next)
+		return
+	case *joinError:
 		_ = enc.AddObject("next", next)
 		return
 	}
@@ -227,13 +271,34 @@ func NewInternalError(err error, reason string, detail ...any) error {
 	return NewWithSkip(err, bstatus.New(bcode.InternalError, reason, ds), 1)
 }
 
+// CodeOf the code of the outermost Error in the chain, the wrapped standard-library errors are walked too.
+// returns bcode.OK for a nil error and bcode.Unknown if there is no Error in the chain.
+func CodeOf(err error) bcode.Code {
+	if err == nil {
+		return bcode.OK
+	}
+	return statusCode(StatusOf(err))
+}
+
+// StatusOf the status of the outermost Error in the chain, the wrapped standard-library errors are walked too.
+// returns bstatus.OK for a nil error and bstatus.Unknown if there is no Error in the chain.
+func StatusOf(err error) bstatus.Status {
+	if err == nil {
+		return bstatus.OK
+	}
+	if e, ok := asError(err); ok && e.Status() != nil {
+		return e.Status()
+	}
+	return bstatus.Unknown
+}
+
 // IsCode determine whether the error code of err meets expectations.
 func IsCode(err error, code bcode.Code) bool {
 	if err == nil {
 		return false
 	}
-	var e Error
-	if ok := errors.As(err, &e); !ok {
+	e, ok := asError(err)
+	if !ok {
 		return false
 	}
 	if e.Status().Code().ToInt() == code.ToInt() {
@@ -3,6 +3,7 @@ package berror_test
 import (
 	"errors"
 	"fmt"
+	"io"
 	"testing"
 
 	"github.com/lamber92/go-brick/berror"
@@ -111,3 +112,51 @@ func TestTypeSwitch(t *testing.T) {
 	}
 	// berror.Chain {"code":500,"reason":"some error 2","detail":null,"next":null}
 }
+
+func BenchmarkNewNotFound(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = berror.NewNotFound(nil, "not found")
+	}
+}
+
+func BenchmarkNewNotFoundAndPrintStack(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = berror.NewNotFound(nil, "not found").(berror.Error).Stack().Error()
+	}
+}
+
+func TestDefaultError_IsCode(t *testing.T) {
+	sentinel := berror.New(bstatus.New(bcode.New(10001), "sentinel", nil))
+	err := fmt.Errorf("wrapped: %w", berror.NewInternalError(berror.NewWithSkip(nil, bstatus.New(bcode.New(10001), "other", nil), 0), "internal"))
+	assert.Equal(t, true, errors.Is(err, sentinel))
+	assert.Equal(t, true, errors.Is(err, bstatus.InternalError))
+	assert.Equal(t, false, errors.Is(err, bstatus.NotFound))
+	assert.Equal(t, false, errors.Is(errors.New("a"), bstatus.Unknown))
+	// the aggregate code of the joined errors counts too
+	joined := berror.Join(berror.NewNotFound(nil, "not found"), berror.NewInternalError(nil, "internal"))
+	assert.Equal(t, true, errors.Is(joined, bstatus.NotFound))
+	assert.Equal(t, true, errors.Is(joined, bstatus.InternalError))
+}
+
+func TestDefaultError_IsBCode(t *testing.T) {
+	assert.Equal(t, true, errors.Is(berror.NewNotFound(nil, "not found"), bcode.NotFound))
+	assert.Equal(t, true, errors.Is(fmt.Errorf("wrapped: %w", berror.NewNotFound(io.EOF, "not found")), bcode.NotFound))
+	assert.Equal(t, false, errors.Is(berror.NewNotFound(nil, "not found"), bcode.InternalError))
+	assert.Equal(t, true, errors.Is(berror.New(bstatus.New(bcode.New(10001), "custom", nil)), bcode.New(10001).(error)))
+	assert.Equal(t, "404", bcode.NotFound.Error())
+	assert.Equal(t, "404", fmt.Sprintf("%d", bcode.NotFound))
+}
+
+func TestCodeOf(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", berror.NewNotFound(io.EOF, "not found"))
+	assert.Equal(t, bcode.NotFound, berror.CodeOf(err))
+	assert.Equal(t, "not found", berror.StatusOf(err).Reason())
+	assert.Equal(t, bcode.OK, berror.CodeOf(nil))
+	assert.Equal(t, bstatus.OK, berror.StatusOf(nil))
+	assert.Equal(t, bcode.Unknown, berror.CodeOf(io.EOF))
+	assert.Equal(t, bstatus.Unknown, berror.StatusOf(io.EOF))
+	joined := berror.Join(berror.NewNotFound(nil, "not found"), berror.NewInternalError(nil, "internal"))
+	assert.Equal(t, bcode.InternalError, berror.CodeOf(joined))
+}
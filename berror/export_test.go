@@ -0,0 +1,6 @@
+package berror
+
+// ResetConverter restore the built-in Converter, dropping the registered hook
+func ResetConverter() {
+	defConv = newDefaultConverter()
+}
@@ -0,0 +1,202 @@
+package berror
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/lamber92/go-brick/bstack"
+)
+
+// ErrorFunc render the Error() of the errors created by berror.
+// nb. it must not call err.Error(), which calls it back.
+type ErrorFunc func(err Error) string
+
+var errorFunc ErrorFunc = JSONError
+
+// ReplaceErrorFunc overrides the rendering of Error(), JSONError by default.
+// nb. this function is not thread-safe, call it when you initialize the program.
+func ReplaceErrorFunc(f ErrorFunc) {
+	if f == nil {
+		f = JSONError
+	}
+	errorFunc = f
+}
+
+// JSONError render the code, reason and detail of the whole chain as JSON, the same as MarshalJSON
+func JSONError(err Error) string {
+	if d, ok := err.(*defaultError); ok {
+		str, _ := jsonStdIter.MarshalToString(d.format(!isDebug()))
+		return str
+	}
+	return err.Error()
+}
+
+// ChainError render the reasons of the chain joined by ": ", the same as '%v'
+func ChainError(err Error) string {
+	return reasonChain(err)
+}
+
+// Format fmt.Formatter impl
+//
+//	%s    the reason of the top layer
+//	%q    the quoted reason of the top layer
+//	%v    the reasons of the chain joined by ": "
+//	%+v   the chain layer by layer with codes, details and stacks,
+//	      the frames shared with the next layer are trimmed
+func (d *defaultError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			writeVerbose(s, d, "")
+			return
+		}
+		_, _ = io.WriteString(s, reasonChain(d))
+	case 's':
+		_, _ = io.WriteString(s, topReason(d))
+	case 'q':
+		_, _ = io.WriteString(s, strconv.Quote(topReason(d)))
+	default:
+		_, _ = fmt.Fprintf(s, "%%!%c(berror=%s)", verb, topReason(d))
+	}
+}
+
+// MarshalJSON json.Marshaler impl, the code, reason and detail of the whole chain,
+// the detail is redacted in the environments that can not be debugged.
+func (d *defaultError) MarshalJSON() ([]byte, error) {
+	return jsonStdIter.Marshal(d.format(!isDebug()))
+}
+
+// Format fmt.Formatter impl, see defaultError.Format
+func (j *joinError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			writeVerbose(s, j, "")
+			return
+		}
+		_, _ = io.WriteString(s, reasonChain(j))
+	case 's':
+		_, _ = io.WriteString(s, j.status.Reason())
+	case 'q':
+		_, _ = io.WriteString(s, strconv.Quote(j.status.Reason()))
+	default:
+		_, _ = fmt.Fprintf(s, "%%!%c(berror=%s)", verb, j.status.Reason())
+	}
+}
+
+// MarshalJSON json.Marshaler impl, see defaultError.MarshalJSON
+func (j *joinError) MarshalJSON() ([]byte, error) {
+	return jsonStdIter.Marshal(j.format(!isDebug()))
+}
+
+// topReason the reason of the top layer, or the text of the cause if it has no reason
+func topReason(d *defaultError) string {
+	if d == nil {
+		return ""
+	}
+	if d.status != nil && len(d.status.Reason()) > 0 {
+		return d.status.Reason()
+	}
+	if d.err != nil {
+		return fmt.Sprintf("%s", d.err)
+	}
+	return ""
+}
+
+// reasonChain join the reasons of the layers, the children of Join are wrapped by '[]'
+func reasonChain(err error) string {
+	parts := make([]string, 0, 4)
+	for err != nil {
+		switch tmp := err.(type) {
+		case *defaultError:
+			if tmp.status != nil && len(tmp.status.Reason()) > 0 {
+				parts = append(parts, tmp.status.Reason())
+			}
+			err = tmp.err
+			continue
+		case *joinError:
+			children := make([]string, 0, len(tmp.errs))
+			for _, v := range tmp.errs {
+				children = append(children, reasonChain(v))
+			}
+			parts = append(parts, "["+strings.Join(children, "; ")+"]")
+		case Error:
+			parts = append(parts, tmp.Status().Reason())
+			err = tmp.Cause()
+			continue
+		default:
+			parts = append(parts, err.Error())
+		}
+		break
+	}
+	return strings.Join(parts, ": ")
+}
+
+// writeVerbose write the chain layer by layer
+func writeVerbose(w io.Writer, err error, indent string) {
+	var next bstack.StackList
+	for first := true; err != nil; first = false {
+		if !first {
+			_, _ = io.WriteString(w, "\n"+indent+"caused by: ")
+		} else {
+			_, _ = io.WriteString(w, indent)
+		}
+		switch tmp := err.(type) {
+		case *defaultError:
+			writeLayer(w, tmp, indent)
+			err = tmp.err
+			if inner, ok := err.(*defaultError); ok {
+				next = inner.stack
+			} else {
+				next = bstack.StackList{}
+			}
+			writeFrames(w, trimFrames(tmp.stack.Frames(), next.Frames()), indent)
+			continue
+		case *joinError:
+			_, _ = fmt.Fprintf(w, "[%d] %s (%d errors)", tmp.status.Code().ToInt(), tmp.status.Reason(), len(tmp.errs))
+			for i, v := range tmp.errs {
+				_, _ = fmt.Fprintf(w, "\n%s  #%d:\n", indent, i+1)
+				writeVerbose(w, v, indent+"    ")
+			}
+		default:
+			_, _ = io.WriteString(w, err.Error())
+		}
+		break
+	}
+}
+
+func writeLayer(w io.Writer, d *defaultError, indent string) {
+	if d.status == nil {
+		_, _ = io.WriteString(w, "[nil status]")
+		return
+	}
+	_, _ = fmt.Fprintf(w, "[%d] %s", statusCode(d.status).ToInt(), d.status.Reason())
+	if detail := d.status.Detail(); detail != nil {
+		if !isDebug() {
+			detail = Redact(detail)
+		}
+		raw, err := jsonStdIter.MarshalToString(detail)
+		if err != nil {
+			raw = fmt.Sprintf("%v", detail)
+		}
+		_, _ = fmt.Fprintf(w, "\n%s    detail: %s", indent, raw)
+	}
+}
+
+func writeFrames(w io.Writer, frames []*bstack.Frame, indent string) {
+	for _, f := range frames {
+		_, _ = fmt.Fprintf(w, "\n%s    at %s (%s:%d)", indent, f.Func, f.File, f.Line)
+	}
+}
+
+// trimFrames drop the frames that are shared with the next layer, such as the inherited stack
+func trimFrames(frames, next []*bstack.Frame) []*bstack.Frame {
+	i, j := len(frames)-1, len(next)-1
+	for i >= 0 && j >= 0 && *frames[i] == *next[j] {
+		i--
+		j--
+	}
+	return frames[:i+1]
+}
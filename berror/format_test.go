@@ -0,0 +1,53 @@
+package berror_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFormatTestError() error {
+	err := berror.NewNotFound(errors.New("record not found"), "user not found", map[string]any{"id": 1, "token": "x"})
+	return berror.NewInternalError(err, "load user fail")
+}
+
+func TestDefaultError_Format(t *testing.T) {
+	err := newFormatTestError()
+	assert.Equal(t, "load user fail", fmt.Sprintf("%s", err))
+	assert.Equal(t, `"load user fail"`, fmt.Sprintf("%q", err))
+	assert.Equal(t, "load user fail: user not found: record not found", fmt.Sprintf("%v", err))
+	assert.Equal(t, "wrap: load user fail: user not found: record not found", fmt.Errorf("wrap: %w", err).Error())
+
+	verbose := fmt.Sprintf("%+v", err)
+	lines := strings.Split(verbose, "\n")
+	assert.Equal(t, "[500] load user fail", lines[0])
+	assert.Contains(t, verbose, "caused by: [404] user not found")
+	assert.Contains(t, verbose, `detail: {"id":1,"token":"******"}`)
+	assert.Contains(t, verbose, "caused by: record not found")
+	// the inherited stack is printed once
+	assert.Equal(t, 1, strings.Count(verbose, "at github.com/lamber92/go-brick/berror_test.newFormatTestError"))
+
+	joined := berror.Join(err, errors.New("other"))
+	assert.Equal(t, "[load user fail: user not found: record not found; other]", fmt.Sprintf("%v", joined))
+	assert.Contains(t, fmt.Sprintf("%+v", joined), "#2:\n    other")
+}
+
+func TestDefaultError_MarshalJSON(t *testing.T) {
+	err := newFormatTestError()
+	raw, err2 := json.Marshal(map[string]any{"err": err})
+	assert.Nil(t, err2)
+	assert.JSONEq(t, `{"err":{"code":500,"reason":"load user fail","detail":null,`+
+		`"next":{"code":404,"reason":"user not found","detail":{"id":1,"token":"******"},"next":"record not found"}}}`, string(raw))
+	assert.Equal(t, string(raw), `{"err":`+err.Error()+`}`)
+}
+
+func TestReplaceErrorFunc(t *testing.T) {
+	berror.ReplaceErrorFunc(berror.ChainError)
+	defer berror.ReplaceErrorFunc(nil)
+	assert.Equal(t, "load user fail: user not found: record not found", newFormatTestError().Error())
+}
@@ -0,0 +1,230 @@
+package berror
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/lamber92/go-brick/internal/json"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// grpcMetaDetail the JSON of bstatus.Status.Detail()
+	grpcMetaDetail = "detail"
+	// grpcMetaCause the message of the wrapped error
+	grpcMetaCause = "cause"
+	// grpcMetaAcceptLanguage the metadata key of the preferred locales
+	grpcMetaAcceptLanguage = "accept-language"
+)
+
+// ToGRPCStatus convert err to a gRPC status.
+// the gRPC code is mapped by bcode.ToGRPCCode, and a Detail carrying the code, reason,
+// detail and cause of err is attached, so that FromGRPCStatus can rebuild it on the other side.
+// the detail and the cause are redacted, see Redact.
+// an error that is not Error is converted by Convert, and its message is only kept in the redacted cause,
+// the client gets the preset reason of the code instead.
+// an error that is already a gRPC status is returned as is.
+func ToGRPCStatus(err error) *status.Status {
+	return toGRPCStatus(nil, err)
+}
+
+// toGRPCStatus the message of the status is localized for ctx if it is not nil,
+// while the Detail keeps the canonical reason.
+func toGRPCStatus(ctx context.Context, err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	e, ok := asError(err)
+	if !ok {
+		if st, ok := status.FromError(err); ok {
+			return st
+		}
+		code, cause := bcode.Code(bcode.Unknown), err
+		if conv, ok := asError(Convert(err, "")); ok {
+			code, cause = statusCode(conv.Status()), conv.Cause()
+		} else if tmp, tmpCause, ok := lookupConvRules(err); ok {
+			// the hook of Convert may leave the error as is
+			code, cause = tmp, tmpCause
+		}
+		e = NewWithSkip(cause, bstatus.New(code, bstatus.GetByCode(code).Reason(), nil), 2)
+	}
+	code := statusCode(e.Status())
+	detail := &Detail{
+		Code:     int64(code.ToInt()),
+		Message:  e.Status().Reason(),
+		Metadata: make(map[string]string),
+	}
+	if d := e.Status().Detail(); d != nil {
+		if raw, err2 := json.MarshalToString(Redact(d)); err2 == nil {
+			detail.Metadata[grpcMetaDetail] = raw
+		}
+	}
+	if cause := e.Cause(); cause != nil {
+		detail.Metadata[grpcMetaCause] = RedactError(cause)
+	}
+	msg := detail.Message
+	if ctx != nil {
+		msg = bstatus.Localize(ctx, e.Status())
+	}
+	st := status.New(bcode.ToGRPCCode(code), msg)
+	if tmp, err2 := st.WithDetails(detail); err2 == nil {
+		return tmp
+	}
+	return st
+}
+
+// FromGRPCStatus rebuild the Error from a gRPC status.
+// the code, reason, detail and cause come from the attached Detail if there is one,
+// otherwise the code is mapped by bcode.FromGRPCCode and the reason is the status message.
+// the cause of the returned error is marked as remote, see IsRemote.
+// returns nil for a nil or OK status.
+func FromGRPCStatus(st *status.Status) error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+	return NewWithSkip(newRemoteError(st), statusFromGRPC(st), 1)
+}
+
+// statusFromGRPC build the Status from the attached Detail or the gRPC code
+func statusFromGRPC(st *status.Status) bstatus.Status {
+	for _, v := range st.Details() {
+		detail, ok := v.(*Detail)
+		if !ok {
+			continue
+		}
+		var d any
+		if raw, exist := detail.GetMetadata()[grpcMetaDetail]; exist {
+			if err := json.UnmarshalFromString(raw, &d); err != nil {
+				d = raw
+			}
+		}
+		return bstatus.New(bcode.New(int(detail.GetCode())), detail.GetMessage(), d)
+	}
+	return bstatus.New(bcode.FromGRPCCode(st.Code()), st.Message(), nil)
+}
+
+// IsRemote report whether err is rebuilt from the gRPC status or the HTTP response of another service
+func IsRemote(err error) bool {
+	var r *remoteError
+	return errors.As(err, &r)
+}
+
+// remoteError the origin of an error that is returned by another service.
+// it keeps the gRPC status, so that the error can be passed through to the upstream as is.
+type remoteError struct {
+	st  *status.Status
+	msg string
+}
+
+func newRemoteError(st *status.Status) *remoteError {
+	r := &remoteError{st: st, msg: st.Message()}
+	for _, v := range st.Details() {
+		if detail, ok := v.(*Detail); ok {
+			if cause, exist := detail.GetMetadata()[grpcMetaCause]; exist {
+				r.msg = cause
+			}
+			break
+		}
+	}
+	return r
+}
+
+func (r *remoteError) Error() string {
+	return r.msg
+}
+
+// GRPCStatus return the original status, or an Unknown one for the errors that do not come from gRPC
+func (r *remoteError) GRPCStatus() *status.Status {
+	if r.st == nil {
+		return status.New(codes.Unknown, r.msg)
+	}
+	return r.st
+}
+
+// =======================================
+// ---------- gRPC Interceptors ----------
+// =======================================
+
+// UnaryServerInterceptor convert the errors returned by the handlers with ToGRPCStatus.
+// the message of the status is localized for the locale in ctx or the 'accept-language' metadata.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, toGRPCStatus(localeFromGRPC(ctx), err).Err()
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor convert the errors returned by the handlers with ToGRPCStatus.
+// the message of the status is localized for the locale in ctx or the 'accept-language' metadata.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return toGRPCStatus(localeFromGRPC(ss.Context()), err).Err()
+		}
+		return nil
+	}
+}
+
+// localeFromGRPC take the locales from the 'accept-language' metadata if ctx has none
+func localeFromGRPC(ctx context.Context) context.Context {
+	if len(bstatus.LocalesFromCtx(ctx)) > 0 {
+		return ctx
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(grpcMetaAcceptLanguage); len(v) > 0 {
+			return bstatus.WithLocale(ctx, bstatus.ParseAcceptLanguage(strings.Join(v, ","))...)
+		}
+	}
+	return ctx
+}
+
+// UnaryClientInterceptor convert the errors of the calls with FromGRPCStatus
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return fromGRPCError(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+// StreamClientInterceptor convert the errors of the streams with FromGRPCStatus, io.EOF is kept
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, fromGRPCError(err)
+		}
+		return &clientStream{ClientStream: cs}, nil
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	return fromGRPCError(s.ClientStream.SendMsg(m))
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	return fromGRPCError(s.ClientStream.RecvMsg(m))
+}
+
+func fromGRPCError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return NewWithSkip(newRemoteError(st), statusFromGRPC(st), 2)
+}
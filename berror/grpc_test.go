@@ -0,0 +1,112 @@
+package berror_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testGRPCCode = 10086
+
+type testHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	err error
+}
+
+func (s *testHealthServer) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return nil, s.err
+}
+
+func (s *testHealthServer) Watch(_ *grpc_health_v1.HealthCheckRequest, _ grpc_health_v1.Health_WatchServer) error {
+	return s.err
+}
+
+func newTestGRPCClient(t *testing.T, srvErr error, clientOpts ...grpc.DialOption) grpc_health_v1.HealthClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(berror.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(berror.StreamServerInterceptor()),
+	)
+	grpc_health_v1.RegisterHealthServer(srv, &testHealthServer{err: srvErr})
+	go func() { _ = srv.Serve(lis) }()
+
+	opts := append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, clientOpts...)
+	conn, err := grpc.Dial("bufnet", opts...)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		srv.Stop()
+	})
+	return grpc_health_v1.NewHealthClient(conn)
+}
+
+func TestGRPCStatus(t *testing.T) {
+	err := berror.New(bstatus.New(bcode.New(testGRPCCode), "custom reason", map[string]any{"id": 1}), errors.New("db failed"))
+	st := berror.ToGRPCStatus(err)
+	assert.Equal(t, codes.Unknown, st.Code())
+	assert.Equal(t, "custom reason", st.Message())
+
+	rebuilt := berror.FromGRPCStatus(st)
+	assert.Equal(t, true, berror.IsCode(rebuilt, bcode.New(testGRPCCode)))
+	assert.Equal(t, "custom reason", rebuilt.(berror.Error).Status().Reason())
+	assert.Equal(t, map[string]any{"id": float64(1)}, rebuilt.(berror.Error).Status().Detail())
+	assert.Equal(t, "db failed", rebuilt.(berror.Error).Cause().Error())
+	assert.Equal(t, true, berror.IsRemote(rebuilt))
+	assert.Equal(t, false, berror.IsRemote(err))
+
+	// a plain gRPC status
+	rebuilt = berror.FromGRPCStatus(status.New(codes.NotFound, "missing"))
+	assert.Equal(t, true, berror.IsCode(rebuilt, bcode.NotFound))
+	assert.Equal(t, "missing", rebuilt.(berror.Error).Status().Reason())
+
+	assert.Nil(t, berror.FromGRPCStatus(nil))
+	assert.Nil(t, berror.FromGRPCStatus(status.New(codes.OK, "")))
+	assert.Equal(t, codes.OK, berror.ToGRPCStatus(nil).Code())
+
+	// the plain errors are converted, their messages stay in the cause
+	st = berror.ToGRPCStatus(errors.New("dial tcp 10.0.0.1:3306: connection refused"))
+	assert.Equal(t, codes.Unknown, st.Code())
+	assert.Equal(t, bstatus.Unknown.Reason(), st.Message())
+	rebuilt = berror.FromGRPCStatus(st)
+	assert.Equal(t, "dial tcp 10.0.0.1:3306: connection refused", rebuilt.(berror.Error).Cause().Error())
+	st = berror.ToGRPCStatus(fmt.Errorf("query: %w", context.DeadlineExceeded))
+	assert.Equal(t, codes.DeadlineExceeded, st.Code())
+	assert.NotContains(t, st.Message(), "query")
+}
+
+func TestGRPCInterceptors(t *testing.T) {
+	srvErr := berror.NewNotFound(errors.New("record not found"), "user not found")
+	client := newTestGRPCClient(t, srvErr,
+		grpc.WithUnaryInterceptor(berror.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(berror.StreamClientInterceptor()),
+	)
+
+	_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, true, berror.IsCode(err, bcode.NotFound))
+	assert.Equal(t, "user not found", err.(berror.Error).Status().Reason())
+	assert.Equal(t, true, berror.IsRemote(err))
+	// the status can be passed through
+	assert.Equal(t, codes.NotFound, status.Code(err.(berror.Error).Cause()))
+
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Nil(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, true, berror.IsCode(err, bcode.NotFound))
+	assert.Equal(t, true, berror.IsRemote(err))
+}
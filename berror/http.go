@@ -0,0 +1,151 @@
+package berror
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/lamber92/go-brick/internal/json"
+)
+
+// maxHTTPErrorBody the maximum size of the body FromHTTPResponse reads
+const maxHTTPErrorBody = 1 << 20
+
+// HTTPEnvelope the JSON body rendered by WriteHTTP
+type HTTPEnvelope struct {
+	Code   int    `json:"code"`
+	Reason string `json:"reason"`
+	// Detail only in the environments that can be debugged
+	Detail any `json:"detail,omitempty"`
+	// Cause the nested causes, only in the environments that can be debugged
+	Cause   any    `json:"cause,omitempty"`
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+var traceIDFunc = rawTraceID
+
+// ReplaceTraceIDFunc inject the resolution of the trace-id of ctx, which is rendered into the envelopes.
+// berror can not depend on btrace, btrace injects btrace.GetTraceID when it is loaded,
+// so that the trace-id of the W3C span context is resolved as well.
+// nb. this function is not thread-safe, call it when you initialize the program.
+func ReplaceTraceIDFunc(f func(ctx context.Context) string) {
+	if f == nil {
+		f = rawTraceID
+	}
+	traceIDFunc = f
+}
+
+// rawTraceID the flat trace-id in ctx only
+func rawTraceID(ctx context.Context) string {
+	traceID, _ := ctx.Value(bcontext.TraceID).(string)
+	return traceID
+}
+
+// NewHTTPEnvelope build the envelope of err, the reason is localized for the locales in ctx (see bstatus.Localize).
+// an error that is not Error is converted by Convert first, whose reason is the preset one of its code.
+// the detail and the nested causes are hidden unless the environment can be debugged (see ReplaceAllowDebug),
+// and they are always redacted (see Redact).
+func NewHTTPEnvelope(ctx context.Context, err error) *HTTPEnvelope {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	out := &HTTPEnvelope{}
+	out.TraceID = traceIDFunc(ctx)
+	if err == nil {
+		out.Code = bcode.OK.ToInt()
+		out.Reason = bstatus.Localize(ctx, bstatus.OK)
+		return out
+	}
+	var e *defaultError
+	if tmp, ok := err.(*joinError); ok {
+		e = &defaultError{err: tmp, status: tmp.status, stack: tmp.stack}
+	} else if !errors.As(err, &e) {
+		if tmp, ok := Convert(err, "").(*defaultError); ok {
+			e = tmp
+		} else {
+			e = NewWithSkip(err, bstatus.New(bcode.Unknown, "", nil), 1).(*defaultError)
+		}
+	}
+	code := statusCode(e.Status())
+	out.Code = code.ToInt()
+	out.Reason = bstatus.Localize(ctx, e.Status())
+	if len(out.Reason) == 0 {
+		out.Reason = bstatus.Localize(ctx, bstatus.GetByCode(code))
+	}
+	if isDebug() {
+		out.Detail = Redact(e.Status().Detail())
+		if sum := e.format(true); sum != nil {
+			out.Cause = sum.Next
+		}
+	}
+	return out
+}
+
+// WriteHTTP render err as the JSON envelope, the HTTP status comes from bcode.ToHTTPStatusCode.
+// the codes without a valid http-status-code mapping, such as the unregistered business codes, are responded with 500.
+// the trace-id is taken from ctx.
+func WriteHTTP(w http.ResponseWriter, ctx context.Context, err error) error {
+	env := NewHTTPEnvelope(ctx, err)
+	body, err2 := json.Marshal(env)
+	if err2 != nil {
+		return NewInternalError(err2, "marshal http error envelope fail")
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(httpStatusOf(bcode.New(env.Code)))
+	if _, err2 = w.Write(body); err2 != nil {
+		return Convert(err2, "write http error envelope fail")
+	}
+	return nil
+}
+
+// httpStatusOf map the code to a status accepted by net/http
+func httpStatusOf(code bcode.Code) int {
+	status := bcode.ToHTTPStatusCode(code)
+	if !validHTTPStatus(status) {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
+func validHTTPStatus(status int) bool {
+	return status >= 100 && status <= 599
+}
+
+// FromHTTPResponse rebuild the Error from a response rendered by WriteHTTP.
+// if the body is not an envelope carrying a non-zero error code, the code is mapped by bcode.FromHTTPStatusCode.
+// the cause of the returned error is marked as remote, see IsRemote.
+// returns nil for the responses whose status is below 400.
+//
+// nb. the body is read and replaced, so it can still be read by the caller.
+func FromHTTPResponse(resp *http.Response) error {
+	if resp == nil || resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+	var raw []byte
+	if resp.Body != nil {
+		raw, _ = io.ReadAll(io.LimitReader(resp.Body, maxHTTPErrorBody))
+		_ = resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(raw))
+	}
+
+	// only an envelope with an error code is trusted, the other bodies fall back to the HTTP status
+	env := &HTTPEnvelope{}
+	if err := json.Unmarshal(raw, env); err != nil || env.Code == bcode.OK.ToInt() {
+		code := bcode.FromHTTPStatusCode(resp.StatusCode)
+		return NewWithSkip(&remoteError{msg: resp.Status}, bstatus.New(code, bstatus.GetByCode(code).Reason(), nil), 1)
+	}
+	remote := &remoteError{msg: resp.Status}
+	if env.Cause != nil {
+		if msg, ok := env.Cause.(string); ok {
+			remote.msg = msg
+		} else if tmp, err := json.MarshalToString(env.Cause); err == nil {
+			remote.msg = tmp
+		}
+	}
+	return NewWithSkip(remote, bstatus.New(bcode.New(env.Code), env.Reason, env.Detail), 1)
+}
@@ -0,0 +1,122 @@
+package berror_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/lamber92/go-brick/btrace"
+	"github.com/lamber92/go-brick/internal/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteHTTP(t *testing.T) {
+	ctx := bcontext.New().Set(bcontext.TraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+	err := berror.NewNotFound(errors.New("record not found"), "user not found", map[string]any{"id": 1, "token": "x"})
+
+	// details are hidden by default
+	rec := httptest.NewRecorder()
+	assert.Nil(t, berror.WriteHTTP(rec, ctx, err))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"code":404,"reason":"user not found","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"}`, rec.Body.String())
+
+	berror.ReplaceAllowDebug(func() bool { return true })
+	defer berror.ReplaceAllowDebug(nil)
+	rec = httptest.NewRecorder()
+	assert.Nil(t, berror.WriteHTTP(rec, ctx, err))
+	assert.JSONEq(t, `{"code":404,"reason":"user not found","detail":{"id":1,"token":"******"},"cause":"record not found","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"}`, rec.Body.String())
+
+	// not Error
+	rec = httptest.NewRecorder()
+	assert.Nil(t, berror.WriteHTTP(rec, context.Background(), errors.New("boom")))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"code":-1,"reason":"Unknown Error","cause":"boom"}`, rec.Body.String())
+
+	// a business code without http-status-code mapping
+	rec = httptest.NewRecorder()
+	assert.Nil(t, berror.WriteHTTP(rec, context.Background(), berror.New(bstatus.New(bcode.New(5010001), "order locked", nil))))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, float64(5010001), decodeBody(t, rec)["code"])
+}
+
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+	out := map[string]any{}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	return out
+}
+
+func TestNewHTTPEnvelope_TraceID(t *testing.T) {
+	sc := btrace.NewRootSpanContext(context.Background())
+	// only the W3C span context is carried
+	ctx := context.WithValue(context.Background(), btrace.KeySpanContext, sc)
+	env := berror.NewHTTPEnvelope(ctx, berror.NewNotFound(nil, "not found"))
+	assert.Equal(t, sc.TraceID.String(), env.TraceID)
+}
+
+func TestFromHTTPResponse(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/berror", func(w http.ResponseWriter, r *http.Request) {
+		_ = berror.WriteHTTP(w, r.Context(), berror.NewAlreadyExists(nil, "user already exists"))
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/code0", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"code":0,"reason":"something went wrong"}`))
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/berror")
+	assert.Nil(t, err)
+	err = berror.FromHTTPResponse(resp)
+	assert.Equal(t, true, berror.IsCode(err, bcode.AlreadyExists))
+	assert.Equal(t, "user already exists", err.(berror.Error).Status().Reason())
+	assert.Equal(t, true, berror.IsRemote(err))
+	// the body can still be read
+	body, _ := io.ReadAll(resp.Body)
+	assert.True(t, strings.Contains(string(body), "user already exists"))
+
+	resp, err = http.Get(srv.URL + "/plain")
+	assert.Nil(t, err)
+	err = berror.FromHTTPResponse(resp)
+	assert.Equal(t, true, berror.IsCode(err, bcode.ServiceUnavailable))
+	assert.Equal(t, "503 Service Unavailable", err.(berror.Error).Cause().Error())
+
+	// an envelope without an error code is not trusted
+	resp, err = http.Get(srv.URL + "/code0")
+	assert.Nil(t, err)
+	err = berror.FromHTTPResponse(resp)
+	assert.Equal(t, true, berror.IsCode(err, bcode.InternalError))
+
+	resp, err = http.Get(srv.URL + "/ok")
+	assert.Nil(t, err)
+	assert.Nil(t, berror.FromHTTPResponse(resp))
+}
+
+func TestWriteHTTPLocalized(t *testing.T) {
+	bstatus.ReplaceCatalog(bstatus.NewMapCatalog(map[string]map[string]string{"zh": {"404": "资源不存在"}}), "")
+	defer bstatus.ReplaceCatalog(nil, "")
+
+	ctx := bstatus.WithLocale(context.Background(), bstatus.ParseAcceptLanguage("zh-CN,zh;q=0.9")...)
+	rec := httptest.NewRecorder()
+	assert.Nil(t, berror.WriteHTTP(rec, ctx, berror.New(bstatus.NotFound)))
+	assert.JSONEq(t, `{"code":404,"reason":"资源不存在"}`, rec.Body.String())
+	// a business-specific reason is kept
+	rec = httptest.NewRecorder()
+	assert.Nil(t, berror.WriteHTTP(rec, ctx, berror.NewNotFound(nil, "user not found")))
+	assert.JSONEq(t, `{"code":404,"reason":"user not found"}`, rec.Body.String())
+}
@@ -0,0 +1,172 @@
+package berror
+
+import (
+	"errors"
+
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/lamber92/go-brick/bstack"
+	"go.uber.org/zap/zapcore"
+)
+
+// JoinCodeRule choose the code of the error joined by Join from the codes of its children.
+// the status of the first child with the chosen code becomes the status of the joined error.
+type JoinCodeRule func(codes []bcode.Code) bcode.Code
+
+var joinCodeRule JoinCodeRule = DefaultJoinCodeRule
+
+// ReplaceJoinCodeRule overrides the rule consulted by Join.
+// nb. this function is not thread-safe, call it when you initialize the program.
+func ReplaceJoinCodeRule(r JoinCodeRule) {
+	if r == nil {
+		r = DefaultJoinCodeRule
+	}
+	joinCodeRule = r
+}
+
+// DefaultJoinCodeRule the code of the most severe bcode.Level wins, the first one wins a tie
+func DefaultJoinCodeRule(codes []bcode.Code) bcode.Code {
+	if len(codes) == 0 {
+		return bcode.Unknown
+	}
+	out := codes[0]
+	for _, c := range codes[1:] {
+		if bcode.GetLevel(c) > bcode.GetLevel(out) {
+			out = c
+		}
+	}
+	return out
+}
+
+// Join return an error that wraps the given errors, each of them keeps its code, reason and stack.
+// the nil errors are discarded, returns nil if there is none left,
+// and the error itself if there is only one. the errors joined by Join are flattened.
+// the code of the joined error is chosen by the JoinCodeRule, see ReplaceJoinCodeRule.
+//
+// it provides 'Unwrap() []error', so that errors.Is and errors.As traverse every child with Go 1.20+.
+func Join(errs ...error) error {
+	children := make([]error, 0, len(errs))
+	for _, err := range errs {
+		switch tmp := err.(type) {
+		case nil:
+		case *joinError:
+			children = append(children, tmp.errs...)
+		default:
+			children = append(children, err)
+		}
+	}
+	switch len(children) {
+	case 0:
+		return nil
+	case 1:
+		return children[0]
+	}
+
+	codes := make([]bcode.Code, 0, len(children))
+	for _, err := range children {
+		codes = append(codes, CodeOf(err))
+	}
+	j := &joinError{errs: children}
+	code := joinCodeRule(codes)
+	for i, c := range codes {
+		if c.ToInt() != code.ToInt() {
+			continue
+		}
+		if e, ok := asError(children[i]); ok {
+			j.status = e.Status()
+			j.stack = e.Stack()
+		}
+		break
+	}
+	if j.status == nil {
+		j.status = bstatus.New(code, bstatus.GetByCode(code).Reason(), nil)
+	}
+	return j
+}
+
+// joinError the error returned by Join.
+// nb. it can not implement Error, whose Unwrap returns a single error.
+type joinError struct {
+	errs   []error
+	status bstatus.Status   // status of the child chosen by the JoinCodeRule
+	stack  bstack.StackList // stack of the child chosen by the JoinCodeRule
+}
+
+// Error output the code, reason and all children in string format
+func (j *joinError) Error() string {
+	str, _ := jsonStdIter.MarshalToString(j.format(!isDebug()))
+	return str
+}
+
+// Status get the aggregate status
+func (j *joinError) Status() bstatus.Status {
+	return j.status
+}
+
+// Stack the stack of the child chosen by the JoinCodeRule
+func (j *joinError) Stack() bstack.StackList {
+	return j.stack
+}
+
+// Is match the aggregate code like defaultError.Is, the children are matched by errors.Is itself
+func (j *joinError) Is(target error) bool {
+	return (&defaultError{status: j.status}).Is(target)
+}
+
+// Unwrap provides compatibility for Go 1.20 multiple error chains.
+func (j *joinError) Unwrap() []error {
+	return j.errs
+}
+
+type joinSummary struct {
+	Code   bcode.Code `json:"code"`
+	Reason string     `json:"reason"`
+	Errors []any      `json:"errors"`
+}
+
+func (j *joinError) format(redact bool) *joinSummary {
+	sum := &joinSummary{
+		Code:   j.status.Code(),
+		Reason: j.status.Reason(),
+		Errors: make([]any, 0, len(j.errs)),
+	}
+	for _, err := range j.errs {
+		if e, ok := err.(*defaultError); ok {
+			sum.Errors = append(sum.Errors, e.format(redact))
+		} else {
+			sum.Errors = append(sum.Errors, err.Error())
+		}
+	}
+	return sum
+}
+
+// MarshalLogObject zapcore.ObjectMarshaler impl
+func (j *joinError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("code", j.status.Code().ToInt())
+	enc.AddString("reason", j.status.Reason())
+	return enc.AddArray("errors", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+		for _, err := range j.errs {
+			if obj, ok := err.(zapcore.ObjectMarshaler); ok {
+				if err2 := arr.AppendObject(obj); err2 != nil {
+					return err2
+				}
+				continue
+			}
+			arr.AppendString(err.Error())
+		}
+		return nil
+	}))
+}
+
+// asError find the outermost Error of the chain,
+// the error joined by Join is seen as an Error with its aggregate status and wraps itself.
+func asError(err error) (Error, bool) {
+	if j, ok := err.(*joinError); ok {
+		return &defaultError{err: j, status: j.status, stack: j.stack}, true
+	}
+	var e Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
@@ -0,0 +1,71 @@
+package berror_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestJoin(t *testing.T) {
+	assert.Nil(t, berror.Join())
+	assert.Nil(t, berror.Join(nil, nil))
+	single := berror.NewNotFound(nil, "not found")
+	assert.Equal(t, single, berror.Join(nil, single))
+
+	notFound := berror.NewNotFound(io.EOF, "not found")
+	internal := berror.NewInternalError(nil, "internal error")
+	timeout := berror.NewRequestTimeout(nil, "timeout")
+	err := berror.Join(notFound, nil, internal, timeout)
+
+	// the most severe level wins
+	assert.Equal(t, true, berror.IsCode(err, bcode.InternalError))
+	// every child is traversed
+	assert.Equal(t, true, errors.Is(err, io.EOF))
+	assert.Equal(t, true, errors.Is(err, timeout))
+	children := err.(interface{ Unwrap() []error }).Unwrap()
+	assert.Equal(t, []error{notFound, internal, timeout}, children)
+
+	// flattened
+	other := errors.New("other")
+	joined := berror.Join(err, other)
+	assert.Equal(t, 4, len(joined.(interface{ Unwrap() []error }).Unwrap()))
+	assert.Contains(t, joined.Error(), "other")
+	assert.Contains(t, joined.Error(), "timeout")
+
+	// wrapped by an Error
+	wrapped := berror.NewGatewayTimeout(err, "gateway timeout")
+	assert.Equal(t, true, berror.IsCode(wrapped, bcode.GatewayTimeout))
+	assert.Equal(t, true, errors.Is(wrapped, io.EOF))
+	assert.Contains(t, wrapped.Error(), "internal error")
+}
+
+func TestJoin_MarshalLogObject(t *testing.T) {
+	err := berror.Join(berror.NewNotFound(nil, "not found"), errors.New("other"))
+	enc := zapcore.NewMapObjectEncoder()
+	assert.Nil(t, err.(zapcore.ObjectMarshaler).MarshalLogObject(enc))
+	// an error that is not Error counts as bcode.Unknown
+	assert.Equal(t, bcode.Unknown.ToInt(), enc.Fields["code"])
+	children := enc.Fields["errors"].([]any)
+	assert.Equal(t, 2, len(children))
+	assert.Equal(t, "not found", children[0].(map[string]any)["reason"])
+	assert.Equal(t, "other", children[1])
+}
+
+func TestReplaceJoinCodeRule(t *testing.T) {
+	berror.ReplaceJoinCodeRule(func(codes []bcode.Code) bcode.Code {
+		return codes[len(codes)-1]
+	})
+	defer berror.ReplaceJoinCodeRule(nil)
+	err := berror.Join(berror.NewInternalError(nil, "internal error"), berror.NewNotFound(nil, "not found"))
+	assert.Equal(t, true, berror.IsCode(err, bcode.NotFound))
+
+	// no child carries the code
+	berror.ReplaceJoinCodeRule(func([]bcode.Code) bcode.Code { return bcode.Forbidden })
+	err = berror.Join(errors.New("a"), errors.New("b"))
+	assert.Equal(t, true, berror.IsCode(err, bcode.Forbidden))
+}
@@ -0,0 +1,259 @@
+package berror
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/lamber92/go-brick/bstack"
+	"github.com/lamber92/go-brick/internal/json"
+)
+
+// MarshalOption the options of MarshalJSON
+type MarshalOption func(opts *marshalOptions)
+
+type marshalOptions struct {
+	stack  bool
+	redact bool
+}
+
+// WithStack carry the stacks, the stack inherited from the next layer is carried once
+func WithStack() MarshalOption {
+	return func(opts *marshalOptions) {
+		opts.stack = true
+	}
+}
+
+// WithRedact redact the details (see Redact), the typed details are decoded as plain values then
+func WithRedact() MarshalOption {
+	return func(opts *marshalOptions) {
+		opts.redact = true
+	}
+}
+
+// wireError an Error layer, or the joined errors if Errors is not empty.
+// the leaf errors that are not Error are JSON strings.
+type wireError struct {
+	Code       int             `json:"code"`
+	Reason     string          `json:"reason"`
+	Detail     any             `json:"detail,omitempty"`
+	DetailType string          `json:"detail_type,omitempty"`
+	Stack      []*bstack.Frame `json:"stack,omitempty"`
+	Next       any             `json:"next,omitempty"`
+	Errors     []any           `json:"errors,omitempty"`
+}
+
+// rawWireError wireError on the way in
+type rawWireError struct {
+	Code       int                  `json:"code"`
+	Reason     string               `json:"reason"`
+	Detail     stdjson.RawMessage   `json:"detail"`
+	DetailType string               `json:"detail_type"`
+	Stack      []*bstack.Frame      `json:"stack"`
+	Next       stdjson.RawMessage   `json:"next"`
+	Errors     []stdjson.RawMessage `json:"errors"`
+}
+
+// MarshalJSON encode the chain of err, so that UnmarshalJSON can rebuild it in another process.
+// the code, reason and detail of every layer are kept, the leaf errors that are not Error keep their messages.
+// the details are not redacted unless WithRedact is given, and the stacks are dropped unless WithStack is given.
+// the output of Error() (see JSONError) is a valid input of UnmarshalJSON as well.
+func MarshalJSON(err error, options ...MarshalOption) ([]byte, error) {
+	opts := &marshalOptions{}
+	for _, o := range options {
+		o(opts)
+	}
+	raw, err2 := json.Marshal(toWire(err, opts))
+	if err2 != nil {
+		return nil, NewInternalError(err2, "marshal error fail")
+	}
+	return raw, nil
+}
+
+func toWire(err error, opts *marshalOptions) any {
+	switch tmp := err.(type) {
+	case nil:
+		return nil
+	case *joinError:
+		out := &wireError{Code: statusCode(tmp.status).ToInt(), Reason: tmp.status.Reason(), Errors: make([]any, 0, len(tmp.errs))}
+		for _, v := range tmp.errs {
+			out.Errors = append(out.Errors, toWire(v, opts))
+		}
+		return out
+	case Error:
+		st := tmp.Status()
+		if st == nil {
+			st = bstatus.Unknown
+		}
+		out := &wireError{Code: statusCode(st).ToInt(), Reason: st.Reason(), Detail: st.Detail()}
+		if opts.redact {
+			out.Detail = Redact(out.Detail)
+		} else if out.Detail != nil {
+			out.DetailType = detailTypeName(out.Detail)
+		}
+		cause := tmp.Cause()
+		if opts.stack {
+			frames := tmp.Stack().Frames()
+			var next []*bstack.Frame
+			if inner, ok := cause.(Error); ok {
+				next = inner.Stack().Frames()
+			}
+			if !sameFrames(frames, next) {
+				out.Stack = frames
+			}
+		}
+		out.Next = toWire(cause, opts)
+		return out
+	}
+	return err.Error()
+}
+
+func sameFrames(a, b []*bstack.Frame) bool {
+	if len(a) != len(b) || len(a) == 0 {
+		return false
+	}
+	for i := range a {
+		if *a[i] != *b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// UnmarshalJSON rebuild the chain encoded by MarshalJSON.
+// returns the rebuilt error, which is nil for 'null', and the error of decoding.
+// the leaf errors that are not Error become opaque errors with the same messages, which are marked as remote (see IsRemote).
+// a chain joined by Join is returned as an Error with its aggregate status that wraps the join, like CodeOf sees it.
+// the details of the types registered by RegisterDetailType are decoded into them, the others are plain JSON values.
+// a layer without stack inherits the stack of the next layer, like New does.
+func UnmarshalJSON(data []byte) (Error, error) {
+	err, err2 := fromWire(data)
+	if err2 != nil {
+		return nil, NewInvalidArgument(err2, "unmarshal error fail")
+	}
+	if err == nil {
+		return nil, nil
+	}
+	if e, ok := asError(err); ok {
+		return e, nil
+	}
+	// a bare message
+	return &defaultError{err: err, status: bstatus.Unknown}, nil
+}
+
+func fromWire(data []byte) (error, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	if data[0] == '"' {
+		var msg string
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, err
+		}
+		return &remoteError{msg: msg}, nil
+	}
+
+	w := &rawWireError{}
+	if err := json.Unmarshal(data, w); err != nil {
+		return nil, err
+	}
+	detail, err := decodeDetail(w.Detail, w.DetailType)
+	if err != nil {
+		return nil, err
+	}
+	st := bstatus.New(bcode.New(w.Code), w.Reason, detail)
+
+	if len(w.Errors) > 0 {
+		j := &joinError{errs: make([]error, 0, len(w.Errors)), status: st}
+		for _, v := range w.Errors {
+			child, err2 := fromWire(v)
+			if err2 != nil {
+				return nil, err2
+			}
+			if child == nil {
+				continue
+			}
+			j.errs = append(j.errs, child)
+			if e, ok := child.(*defaultError); ok && j.stack.IsEmpty() && statusCode(e.status).ToInt() == w.Code {
+				j.stack = e.stack
+			}
+		}
+		return j, nil
+	}
+
+	next, err := fromWire(w.Next)
+	if err != nil {
+		return nil, err
+	}
+	e := &defaultError{err: next, status: st}
+	if len(w.Stack) > 0 {
+		e.stack = bstack.NewStackList(w.Stack)
+	} else if inner, ok := next.(*defaultError); ok {
+		e.stack = inner.stack
+	}
+	return e, nil
+}
+
+// =======================================
+// -------- Typed Detail Registry --------
+// =======================================
+
+var (
+	detailTypes     = make(map[string]reflect.Type)
+	detailTypeNames = make(map[reflect.Type]string)
+	detailTypesMu   sync.RWMutex
+)
+
+// RegisterDetailType register the type of details by name, so that UnmarshalJSON decodes the details of T into T.
+// T is usually a struct or a pointer to a struct, such as RegisterDetailType[*OrderDetail]("order").
+func RegisterDetailType[T any](name string) error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	detailTypesMu.Lock()
+	defer detailTypesMu.Unlock()
+	if exist, ok := detailTypes[name]; ok {
+		return NewAlreadyExists(nil, fmt.Sprintf("detail type name '%s' is already registered by %s", name, exist))
+	}
+	if exist, ok := detailTypeNames[t]; ok {
+		return NewAlreadyExists(nil, fmt.Sprintf("detail type %s is already registered as '%s'", t, exist))
+	}
+	detailTypes[name] = t
+	detailTypeNames[t] = name
+	return nil
+}
+
+func detailTypeName(detail any) string {
+	detailTypesMu.RLock()
+	defer detailTypesMu.RUnlock()
+	return detailTypeNames[reflect.TypeOf(detail)]
+}
+
+func decodeDetail(raw stdjson.RawMessage, name string) (any, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	detailTypesMu.RLock()
+	t, ok := detailTypes[name]
+	detailTypesMu.RUnlock()
+	if !ok || len(name) == 0 {
+		var out any
+		if err := json.Unmarshal(raw, &out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+	if t.Kind() == reflect.Pointer {
+		ptr := reflect.New(t.Elem())
+		if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+			return nil, err
+		}
+		return ptr.Interface(), nil
+	}
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+		return nil, err
+	}
+	return ptr.Elem().Interface(), nil
+}
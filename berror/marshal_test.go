@@ -0,0 +1,82 @@
+package berror_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/stretchr/testify/assert"
+)
+
+type testOrderDetail struct {
+	OrderID int64  `json:"order_id"`
+	State   string `json:"state"`
+}
+
+// registered once at the package level, so that the test can be run repeatedly
+var errRegisterTestOrder = berror.RegisterDetailType[*testOrderDetail]("test_order")
+
+func TestMarshalJSON(t *testing.T) {
+	assert.Nil(t, errRegisterTestOrder)
+	assert.NotNil(t, berror.RegisterDetailType[*testOrderDetail]("test_order_2"))
+
+	leaf := errors.New("record not found")
+	inner := berror.NewNotFound(leaf, "order not found", &testOrderDetail{OrderID: 1, State: "locked"})
+	err := berror.NewInternalError(inner, "load order fail", map[string]any{"retry": 1})
+
+	raw, err2 := berror.MarshalJSON(err)
+	assert.Nil(t, err2)
+	rebuilt, err2 := berror.UnmarshalJSON(raw)
+	assert.Nil(t, err2)
+
+	assert.Equal(t, bcode.InternalError.ToInt(), berror.CodeOf(rebuilt).ToInt())
+	assert.Equal(t, "load order fail: order not found: record not found", berror.ChainError(rebuilt))
+	assert.Equal(t, map[string]any{"retry": float64(1)}, berror.StatusOf(rebuilt).Detail())
+	next := rebuilt.Cause()
+	assert.Equal(t, true, errors.Is(next, bstatus.NotFound))
+	assert.Equal(t, &testOrderDetail{OrderID: 1, State: "locked"}, berror.StatusOf(next).Detail())
+	// the leaf is opaque
+	assert.Equal(t, "record not found", next.(berror.Error).Cause().Error())
+	assert.Equal(t, true, berror.IsRemote(rebuilt))
+	// no stack by default
+	assert.Equal(t, true, rebuilt.Stack().IsEmpty())
+
+	// the output of Error() is accepted as well
+	rebuilt, err2 = berror.UnmarshalJSON([]byte(err.Error()))
+	assert.Nil(t, err2)
+	assert.Equal(t, bcode.InternalError.ToInt(), berror.CodeOf(rebuilt).ToInt())
+
+	rebuilt, err2 = berror.UnmarshalJSON([]byte("null"))
+	assert.Nil(t, rebuilt)
+	assert.Nil(t, err2)
+	// a bare message is an Unknown error wrapping the remote one
+	rebuilt, err2 = berror.UnmarshalJSON([]byte(`"db down"`))
+	assert.Nil(t, err2)
+	assert.Equal(t, bcode.Unknown.ToInt(), berror.CodeOf(rebuilt).ToInt())
+	assert.Equal(t, true, berror.IsRemote(rebuilt))
+	assert.Equal(t, "db down", rebuilt.Cause().Error())
+	_, err2 = berror.UnmarshalJSON([]byte("{"))
+	assert.Equal(t, true, berror.IsCode(err2, bcode.InvalidArgument))
+}
+
+func TestMarshalJSON_Options(t *testing.T) {
+	inner := berror.NewGatewayTimeout(nil, "timeout", map[string]any{"token": "x"})
+	err := berror.Join(berror.NewInternalError(inner, "internal"), errors.New("other"))
+
+	raw, err2 := berror.MarshalJSON(err, berror.WithStack(), berror.WithRedact())
+	assert.Nil(t, err2)
+	assert.NotContains(t, string(raw), `"x"`)
+	rebuilt, err2 := berror.UnmarshalJSON(raw)
+	assert.Nil(t, err2)
+
+	children := rebuilt.Cause().(interface{ Unwrap() []error }).Unwrap()
+	assert.Equal(t, 2, len(children))
+	assert.Equal(t, bcode.InternalError.ToInt(), berror.CodeOf(rebuilt).ToInt())
+	// the inherited stack is carried once and inherited again
+	first := children[0].(berror.Error)
+	assert.Equal(t, inner.(berror.Error).Stack().Frames(), first.Stack().Frames())
+	assert.Equal(t, first.Stack().Frames(), first.Cause().(berror.Error).Stack().Frames())
+	assert.Equal(t, "other", children[1].Error())
+}
@@ -0,0 +1,216 @@
+package berror
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// sensitiveTag the struct fields tagged with `berror:"sensitive"` are redacted
+	sensitiveTag   = "berror"
+	sensitiveValue = "sensitive"
+
+	redactedValue  = "******"
+	maxRedactDepth = 16
+)
+
+// DefaultSensitiveKeys the key patterns redacted by default
+var DefaultSensitiveKeys = []string{
+	`(?i)passw(or)?d`,
+	`(?i)secret`,
+	`(?i)token`,
+	`(?i)authorization`,
+	`(?i)cookie`,
+	`(?i)api[_-]?key`,
+}
+
+var sensitiveKeys atomic.Value // []*regexp.Regexp
+
+func init() {
+	if err := ReplaceSensitiveKeys(DefaultSensitiveKeys...); err != nil {
+		panic(err)
+	}
+}
+
+// ReplaceSensitiveKeys set the regular expressions matched against the keys of the maps and the (json) names of the struct fields,
+// the values of the matched ones are redacted. it is thread-safe, an empty list disables the key matching.
+func ReplaceSensitiveKeys(patterns ...string) error {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return NewInvalidArgument(err, "invalid sensitive key pattern: "+p)
+		}
+		res = append(res, re)
+	}
+	sensitiveKeys.Store(res)
+	return nil
+}
+
+// Redact return a copy of v in which the sensitive values are replaced,
+// the result is made of maps, slices and the leaf values, which is rendered like v by JSON.
+// a value is sensitive if it is a struct field tagged with `berror:"sensitive"`,
+// or its key matches the patterns of ReplaceSensitiveKeys.
+//
+// it is applied to the detail of the errors when they are logged in the environments that can not be debugged,
+// rendered to HTTP or gRPC clients, or printed into the trace metadata.
+func Redact(v any) any {
+	if v == nil {
+		return nil
+	}
+	switch v.(type) {
+	case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	}
+	return redactValue(reflect.ValueOf(v), sensitiveKeys.Load().([]*regexp.Regexp), 0)
+}
+
+// RedactError the string of err whose details are redacted (see Redact) regardless of the environment,
+// use it when err leaves the process.
+func RedactError(err error) string {
+	switch tmp := err.(type) {
+	case nil:
+		return ""
+	case *defaultError:
+		str, _ := jsonStdIter.MarshalToString(tmp.format(true))
+		return str
+	case *joinError:
+		str, _ := jsonStdIter.MarshalToString(tmp.format(true))
+		return str
+	}
+	return err.Error()
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func redactValue(v reflect.Value, keys []*regexp.Regexp, depth int) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if depth > maxRedactDepth {
+		return fmt.Sprintf("(too deep: %s)", v.Type())
+	}
+	// the types render themselves, such as time.Time
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		if v.CanInterface() {
+			return v.Interface()
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem(), keys, depth+1)
+	case reflect.Struct:
+		out := make(map[string]any, v.NumField())
+		redactStruct(v, keys, depth, out)
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			if matchSensitiveKey(keys, key) {
+				out[key] = redactedValue
+				continue
+			}
+			out[key] = redactValue(iter.Value(), keys, depth+1)
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		out := make([]any, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out = append(out, redactValue(v.Index(i), keys, depth+1))
+		}
+		return out
+	}
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return nil
+}
+
+// redactStruct put the exported fields into out by their json names, the embedded structs are flattened
+func redactStruct(v reflect.Value, keys []*regexp.Regexp, depth int, out map[string]any) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitEmpty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+		fv := v.Field(i)
+		if field.Anonymous && len(name) == 0 {
+			inner := fv
+			if inner.Kind() == reflect.Pointer {
+				if inner.IsNil() {
+					continue
+				}
+				inner = inner.Elem()
+			}
+			if inner.Kind() == reflect.Struct {
+				redactStruct(inner, keys, depth+1, out)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+		if field.Tag.Get(sensitiveTag) == sensitiveValue || matchSensitiveKey(keys, name) {
+			out[name] = redactedValue
+			continue
+		}
+		out[name] = redactValue(fv, keys, depth+1)
+	}
+}
+
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return parts[0], omitEmpty, false
+}
+
+func matchSensitiveKey(keys []*regexp.Regexp, key string) bool {
+	for _, re := range keys {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package berror_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+type testQuery struct {
+	SQL      string `json:"sql" berror:"sensitive"`
+	Table    string `json:"table"`
+	Password string
+	Ignored  string `json:"-"`
+	At       time.Time
+	testEmbedded
+}
+
+type testEmbedded struct {
+	Headers map[string]string `json:"headers"`
+}
+
+func TestRedact(t *testing.T) {
+	at := time.Unix(0, 0)
+	out := berror.Redact(&testQuery{
+		SQL:          "select * from user where token = 'x'",
+		Table:        "user",
+		Password:     "123456",
+		Ignored:      "ignored",
+		At:           at,
+		testEmbedded: testEmbedded{Headers: map[string]string{"Authorization": "Bearer x", "Accept": "*/*"}},
+	}).(map[string]any)
+	assert.Equal(t, map[string]any{
+		"sql":      "******",
+		"table":    "user",
+		"Password": "******",
+		"At":       at,
+		"headers":  map[string]any{"Authorization": "******", "Accept": "*/*"},
+	}, out)
+
+	assert.Equal(t, "plain", berror.Redact("plain"))
+	assert.Equal(t, nil, berror.Redact(nil))
+	assert.Equal(t, []any{map[string]any{"api_key": "******"}}, berror.Redact([]map[string]int{{"api_key": 1}}))
+
+	// custom patterns
+	assert.NotNil(t, berror.ReplaceSensitiveKeys("("))
+	assert.Nil(t, berror.ReplaceSensitiveKeys(`^phone$`))
+	defer func() { _ = berror.ReplaceSensitiveKeys(berror.DefaultSensitiveKeys...) }()
+	assert.Equal(t, map[string]any{"phone": "******", "token": "x"}, berror.Redact(map[string]string{"phone": "1", "token": "x"}))
+}
+
+func TestRedactError(t *testing.T) {
+	detail := map[string]string{"password": "123456", "user": "tom"}
+	err := berror.NewInternalError(berror.New(bstatus.New(bcode.NotFound, "not found", detail)), "internal")
+	for _, str := range []string{err.Error(), berror.RedactError(err), berror.RedactError(berror.Join(err, errors.New("other")))} {
+		assert.NotContains(t, str, "123456")
+		assert.Contains(t, str, "tom")
+	}
+	assert.Equal(t, "other", berror.RedactError(errors.New("other")))
+
+	enc := zapcore.NewMapObjectEncoder()
+	assert.Nil(t, berror.New(bstatus.New(bcode.NotFound, "not found", detail)).(zapcore.ObjectMarshaler).MarshalLogObject(enc))
+	assert.Equal(t, map[string]any{"password": "******", "user": "tom"}, enc.Fields["detail"])
+
+	// kept in the environments that can be debugged
+	berror.ReplaceAllowDebug(func() bool { return true })
+	defer berror.ReplaceAllowDebug(nil)
+	assert.Contains(t, err.Error(), "123456")
+	assert.NotContains(t, berror.RedactError(err), "123456")
+}
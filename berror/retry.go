@@ -0,0 +1,134 @@
+package berror
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// retryMark the explicit retryability of a defaultError, set by MarkRetryable or MarkPermanent
+type retryMark int8
+
+const (
+	retryUnmarked retryMark = iota
+	retryMarked
+	permanentMarked
+)
+
+// MarkRetryable mark err retryable explicitly, which overrides the retryability of the codes and the wrapped errors.
+// the mark is kept in the returned Error, which is a copy of err if err is created by berror,
+// or wraps err with the code converted by the rules (see RegisterConvRule) otherwise.
+func MarkRetryable(err error) error {
+	return markRetry(err, retryMarked)
+}
+
+// MarkPermanent mark err not retryable explicitly, see MarkRetryable
+func MarkPermanent(err error) error {
+	return markRetry(err, permanentMarked)
+}
+
+func markRetry(err error, mark retryMark) error {
+	switch tmp := err.(type) {
+	case nil:
+		return nil
+	case *defaultError:
+		out := *tmp
+		out.retry = mark
+		return &out
+	case *joinError:
+		return &defaultError{err: tmp, status: tmp.status, stack: tmp.stack, retry: mark}
+	case Error:
+		return &defaultError{err: tmp, status: tmp.Status(), stack: tmp.Stack(), retry: mark}
+	}
+	code, cause, ok := lookupConvRules(err)
+	if !ok {
+		code, cause = bcode.Unknown, err
+	}
+	out := NewWithSkip(cause, bstatus.New(code, "", nil), 2).(*defaultError)
+	out.retry = mark
+	return out
+}
+
+// IsRetryable report whether retrying may recover from err, the chain is walked from the outside in:
+//  1. the explicit marks of MarkRetryable and MarkPermanent;
+//  2. the retryability of the codes of Error (see bcode.RegisterRetryability), bcode.Temporary counts as retryable;
+//  3. the network errors such as timeouts and refused connections, the recoverable *amqp.Error and driver.ErrBadConn.
+//
+// the first one that tells decides, an error joined by Join is retryable if any of its children is.
+func IsRetryable(err error) bool {
+	retryable, _ := classifyRetry(err, true)
+	return retryable
+}
+
+// IsTransient the same as IsRetryable, except that the codes are ignored:
+// err is transient if it is marked retryable, or caused by the network, the broker or the driver.
+// use it to decide whether to retry without limit, the retryable codes may be returned for a bad input.
+func IsTransient(err error) bool {
+	retryable, _ := classifyRetry(err, false)
+	return retryable
+}
+
+// classifyRetry returns 'false' for the second value if nothing in the chain tells
+func classifyRetry(err error, byCode bool) (retryable bool, decided bool) {
+	for err != nil {
+		switch tmp := err.(type) {
+		case *joinError:
+			for _, v := range tmp.errs {
+				if r, _ := classifyRetry(v, byCode); r {
+					return true, true
+				}
+			}
+			return false, true
+		case Error:
+			if d, ok := tmp.(*defaultError); ok && d.retry != retryUnmarked {
+				return d.retry == retryMarked, true
+			}
+			if !byCode {
+				break
+			}
+			switch bcode.GetRetryability(statusCode(tmp.Status())) {
+			case bcode.Retryable, bcode.Temporary:
+				return true, true
+			case bcode.Permanent:
+				return false, true
+			}
+		case *amqp.Error:
+			switch tmp.Code {
+			case amqp.ConnectionForced, amqp.ChannelError, amqp.ResourceError:
+				return true, true
+			}
+			return tmp.Recover, true
+		case *net.OpError:
+			// the connection level errors, such as refused or reset
+			return true, true
+		case net.Error:
+			if tmp.Timeout() {
+				return true, true
+			}
+		}
+		switch {
+		case err == context.Canceled:
+			return false, true
+		case err == driver.ErrBadConn, err == io.ErrUnexpectedEOF,
+			err == syscall.ECONNREFUSED, err == syscall.ECONNRESET, err == syscall.EPIPE:
+			return true, true
+		}
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, v := range multi.Unwrap() {
+				if r, _ := classifyRetry(v, byCode); r {
+					return true, true
+				}
+			}
+			return false, false
+		}
+		err = errors.Unwrap(err)
+	}
+	return false, false
+}
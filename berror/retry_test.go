@@ -0,0 +1,91 @@
+package berror_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("unknown"), false},
+		// by code
+		{berror.NewRequestTimeout(nil, "timeout"), true},
+		{berror.New(bstatus.ServiceUnavailable), true},
+		{berror.NewInvalidArgument(refused, "invalid"), false},
+		// by the wrapped errors
+		{berror.NewInternalError(refused, "dial fail"), true},
+		{fmt.Errorf("query: %w", driver.ErrBadConn), true},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{&amqp.Error{Code: amqp.ChannelError}, true},
+		{&amqp.Error{Code: amqp.PreconditionFailed}, false},
+		{berror.Join(errors.New("a"), berror.NewGatewayTimeout(nil, "timeout")), true},
+		// by the marks
+		{berror.MarkRetryable(errors.New("unknown")), true},
+		{berror.MarkPermanent(berror.NewInternalError(refused, "dial fail")), false},
+		{berror.NewInternalError(berror.MarkRetryable(berror.NewNotFound(nil, "not found")), "wrapped"), true},
+	}
+	for i, v := range cases {
+		assert.Equal(t, v.want, berror.IsRetryable(v.err), i)
+	}
+
+	// the mark keeps the code, and the marked errors are Error
+	sentinel := berror.NewNotFound(nil, "not found")
+	err := berror.MarkRetryable(sentinel)
+	assert.Equal(t, bcode.NotFound, berror.CodeOf(err))
+	assert.Equal(t, true, errors.Is(err, sentinel))
+	assert.Equal(t, false, berror.IsRetryable(sentinel))
+	_, ok := err.(berror.Error)
+	assert.Equal(t, true, ok)
+	plain := berror.MarkPermanent(context.DeadlineExceeded)
+	assert.Equal(t, bcode.RequestTimeout, berror.CodeOf(plain))
+	assert.Equal(t, true, errors.Is(plain, context.DeadlineExceeded))
+	assert.Equal(t, false, berror.IsRetryable(plain))
+	assert.Nil(t, berror.MarkRetryable(nil))
+}
+
+func TestIsTransient(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("unknown"), false},
+		// the codes are ignored
+		{berror.NewRequestTimeout(nil, "timeout"), false},
+		{berror.New(bstatus.ServiceUnavailable), false},
+		{berror.NewInvalidArgument(refused, "invalid"), true},
+		{berror.NewInternalError(refused, "dial fail"), true},
+		{&amqp.Error{Code: amqp.ChannelError}, true},
+		{berror.MarkRetryable(berror.NewRequestTimeout(nil, "timeout")), true},
+		{berror.MarkPermanent(berror.NewInternalError(refused, "dial fail")), false},
+	}
+	for i, v := range cases {
+		assert.Equal(t, v.want, berror.IsTransient(v.err), i)
+	}
+}
+
+func TestRegisterRetryability(t *testing.T) {
+	code := bcode.New(9020001)
+	bcode.RegisterRetryability(code, bcode.Temporary)
+	assert.Equal(t, "temporary", bcode.GetRetryability(code).String())
+	assert.Equal(t, true, berror.IsRetryable(berror.New(bstatus.New(code, "busy", nil))))
+	assert.Equal(t, bcode.RetryUnspecified, bcode.GetRetryability(bcode.InternalError))
+}
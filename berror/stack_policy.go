@@ -0,0 +1,64 @@
+package berror
+
+import (
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/bstack"
+)
+
+// StackPolicy decide how the stack of a new error is captured.
+// @allowDebug: whether the current environment can be debugged, see benv.Env.AllowDebug().
+// return 'false' to capture nothing.
+type StackPolicy func(code bcode.Code, level bcode.Level, allowDebug bool) (opts bstack.Options, capture bool)
+
+var (
+	stackPolicy StackPolicy = DefaultStackPolicy
+	allowDebug  func() bool
+)
+
+// ReplaceStackPolicy overrides the policy consulted by New, NewWithSkip and the New* constructors.
+// nb. this function is not thread-safe, call it when you initialize the program.
+func ReplaceStackPolicy(p StackPolicy) {
+	if p == nil {
+		p = DefaultStackPolicy
+	}
+	stackPolicy = p
+}
+
+// ReplaceAllowDebug inject the environment check into the stack policy and the rendering of errors.
+// berror can not depend on benv, bconfig.Init calls it with benv.Env.AllowDebug.
+// the policy is consulted with allowDebug=false until it is injected.
+// nb. this function is not thread-safe, call it when you initialize the program.
+func ReplaceAllowDebug(f func() bool) {
+	allowDebug = f
+}
+
+// isDebug report whether the environment can be debugged, 'false' until ReplaceAllowDebug is called
+func isDebug() bool {
+	return allowDebug != nil && allowDebug()
+}
+
+// DefaultStackPolicy take full stacks in the environments that can be debugged.
+// otherwise, the expected errors of LvInfo and LvNotice take no stack and the rest take the stack of bstack.GetOptions().
+func DefaultStackPolicy(_ bcode.Code, level bcode.Level, allowDebug bool) (bstack.Options, bool) {
+	opts := bstack.GetOptions()
+	if allowDebug {
+		opts.Depth = bstack.StacktraceFull
+		return opts, true
+	}
+	switch level {
+	case bcode.LvInfo, bcode.LvNotice:
+		return opts, false
+	default:
+		return opts, true
+	}
+}
+
+// takeStack capture the stack of the caller according to the policy.
+// skip=0 identifies the caller of takeStack.
+func takeStack(skip int, code bcode.Code) bstack.StackList {
+	opts, capture := stackPolicy(code, bcode.GetLevel(code), isDebug())
+	if !capture {
+		return bstack.StackList{}
+	}
+	return bstack.TakeStackWithOptions(skip+1, opts)
+}
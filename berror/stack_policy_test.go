@@ -0,0 +1,51 @@
+package berror_test
+
+import (
+	"testing"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/bstack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackPolicy(t *testing.T) {
+	defer berror.ReplaceAllowDebug(nil)
+
+	// not injected yet, the policy is consulted with allowDebug=false
+	assert.Equal(t, true, berror.NewNotFound(nil, "not found").(berror.Error).Stack().IsEmpty())
+	assert.Equal(t, false, berror.NewInternalError(nil, "internal").(berror.Error).Stack().IsEmpty())
+
+	debug := false
+	berror.ReplaceAllowDebug(func() bool { return debug })
+	assert.Equal(t, true, berror.NewNotFound(nil, "not found").(berror.Error).Stack().IsEmpty())
+	assert.Equal(t, true, berror.NewInvalidArgument(nil, "invalid").(berror.Error).Stack().IsEmpty())
+	internal := berror.NewInternalError(nil, "internal")
+	assert.Equal(t, false, internal.(berror.Error).Stack().IsEmpty())
+	// wrapping an expected error takes the stack at the wrapping site
+	wrapped := berror.NewInternalError(berror.NewNotFound(nil, "not found"), "internal")
+	assert.Equal(t, false, wrapped.(berror.Error).Stack().IsEmpty())
+
+	debug = true
+	stack := berror.NewNotFound(nil, "not found").(berror.Error).Stack()
+	assert.Greater(t, len(stack.Frames()), 0)
+}
+
+func TestReplaceStackPolicy(t *testing.T) {
+	defer berror.ReplaceAllowDebug(nil)
+	defer berror.ReplaceStackPolicy(nil)
+
+	var debugs []bool
+	berror.ReplaceStackPolicy(func(code bcode.Code, _ bcode.Level, allowDebug bool) (bstack.Options, bool) {
+		debugs = append(debugs, allowDebug)
+		return bstack.Options{Depth: 1}, code == bcode.NotFound
+	})
+	// the policy is consulted before the environment check is injected
+	assert.Equal(t, 1, len(berror.NewNotFound(nil, "not found").(berror.Error).Stack().Frames()))
+	assert.Equal(t, []bool{false}, debugs)
+
+	berror.ReplaceAllowDebug(func() bool { return false })
+	stack := berror.NewNotFound(nil, "not found").(berror.Error).Stack()
+	assert.Equal(t, 1, len(stack.Frames()))
+	assert.Equal(t, true, berror.NewInternalError(nil, "internal").(berror.Error).Stack().IsEmpty())
+}
@@ -0,0 +1,36 @@
+package blog
+
+import (
+	"context"
+	"time"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/blog/logger"
+)
+
+var _aggregator = berror.NewAggregator(0)
+
+// Aggregator return the aggregator behind ErrorOnce, for querying the counted errors
+func Aggregator() *berror.Aggregator {
+	return _aggregator
+}
+
+// ErrorOnce log the error only when its fingerprint (see berror.Fingerprint) is seen for the first time,
+// the following occurrences are counted and reported by StartErrorSummary.
+// the hooks (see RegisterErrorHook) observe every occurrence.
+func ErrorOnce(ctx context.Context, err error, msg string) {
+	fireErrorHooks(ctx, err, msg)
+	rec, first := _aggregator.Add(err)
+	if !first {
+		return
+	}
+	_biz.WithContext(ctx).WithError(err).WithStack(err).
+		Errorw(msg, logger.NewField().String("fingerprint", rec.Fingerprint))
+}
+
+// StartErrorSummary log a summary of the errors counted by ErrorOnce every interval until ctx is done
+func StartErrorSummary(ctx context.Context, interval time.Duration) {
+	go _aggregator.Report(ctx, interval, func(records berror.AggregateRecordList) {
+		logger.Biz.Errorw("error summary", logger.NewField().Any("errors", records))
+	})
+}
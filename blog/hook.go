@@ -0,0 +1,29 @@
+package blog
+
+import (
+	"context"
+)
+
+// ErrorHook observe the errors logged at the error level or above, such as by Error, Errorf, Errorw, ErrorOnce and Panic.
+// msg is the formatted message. it runs in the goroutine of the caller, so it should return quickly.
+type ErrorHook func(ctx context.Context, err error, msg string)
+
+var _errorHooks []ErrorHook
+
+// RegisterErrorHook add a hook observing the errors logged at the error level or above.
+// nb. this function is not thread-safe, call it when you initialize the program.
+func RegisterErrorHook(hook ErrorHook) {
+	if hook == nil {
+		return
+	}
+	_errorHooks = append(_errorHooks, hook)
+}
+
+func fireErrorHooks(ctx context.Context, err error, msg string) {
+	if err == nil {
+		return
+	}
+	for _, hook := range _errorHooks {
+		hook(ctx, err, msg)
+	}
+}
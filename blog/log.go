@@ -2,6 +2,7 @@ package blog
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/lamber92/go-brick/blog/logger"
 )
@@ -21,10 +22,12 @@ func Warn(ctx context.Context, err error, msg string) {
 }
 
 func Error(ctx context.Context, err error, msg string) {
+	fireErrorHooks(ctx, err, msg)
 	_biz.WithContext(ctx).WithError(err).WithStack(err).Error(msg)
 }
 
 func Panic(ctx context.Context, err error, msg string) {
+	fireErrorHooks(ctx, err, msg)
 	_biz.WithContext(ctx).WithError(err).WithStack(err).Panic(msg)
 }
 
@@ -41,10 +44,16 @@ func Warnf(ctx context.Context, err error, format string, a ...any) {
 }
 
 func Errorf(ctx context.Context, err error, format string, a ...any) {
+	if len(_errorHooks) > 0 {
+		fireErrorHooks(ctx, err, fmt.Sprintf(format, a...))
+	}
 	_biz.WithContext(ctx).WithError(err).WithStack(err).Errorf(format, a...)
 }
 
 func Panicf(ctx context.Context, err error, format string, a ...any) {
+	if len(_errorHooks) > 0 {
+		fireErrorHooks(ctx, err, fmt.Sprintf(format, a...))
+	}
 	_biz.WithContext(ctx).WithError(err).WithStack(err).Panicf(format, a...)
 }
 
@@ -61,9 +70,11 @@ func Warnw(ctx context.Context, err error, msg string, fields ...logger.Field) {
 }
 
 func Errorw(ctx context.Context, err error, msg string, fields ...logger.Field) {
+	fireErrorHooks(ctx, err, msg)
 	_biz.WithContext(ctx).WithError(err).WithStack(err).Errorw(msg, fields...)
 }
 
 func Panicw(ctx context.Context, err error, msg string, fields ...logger.Field) {
+	fireErrorHooks(ctx, err, msg)
 	_biz.WithContext(ctx).WithError(err).WithStack(err).Panicw(msg, fields...)
 }
@@ -59,3 +59,17 @@ func TestWithField(t *testing.T) {
 	blog.Infow(ctx, "test with field", blog.Any("name", name))
 	// {"level":"INFO","time":"2023-05-15T15:40:12+08:00","type":"BIZ","func":"go-brick/blog_test.TestWithField","msg":"test with field","trace_id":"29e38f4e13cc48a8826323adbc611073","name":{"FirstName":"Lamber","LastName":"Chen"}}
 }
+
+func TestErrorOnce(t *testing.T) {
+	ctx := bcontext.New().Set(btrace.KeyTraceID, btrace.GenTraceID())
+	var last error
+	for i := 0; i < 3; i++ {
+		last = berror.NewInternalError(nil, "repeated error")
+		blog.ErrorOnce(ctx, last, "test error once message.")
+	}
+	// {"level":"ERROR",...,"msg":"test error once message.","trace_id":"...","fingerprint":"...","err":{...},"stack":[...]}
+	rec, ok := blog.Aggregator().Get(berror.Fingerprint(last))
+	if !ok || rec.Count != 3 {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+}
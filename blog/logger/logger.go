@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/lamber92/go-brick/bcontext"
 	"github.com/lamber92/go-brick/berror"
 	"github.com/lamber92/go-brick/blog/config"
 	"github.com/lamber92/go-brick/bstack"
@@ -51,10 +52,17 @@ func (d *defaultLogger) WithContext(ctx context.Context) Logger {
 	if ctx == nil {
 		return d
 	}
-	tmp := d.engine.With(zap.String("trace_id", btrace.GetTraceID(ctx)))
+	traceID, spanID := spanIDsFromCtx(ctx)
+	tmp := d.engine.With(zap.String("trace_id", traceID))
+	if len(spanID) > 0 {
+		tmp = tmp.With(zap.String("span_id", spanID))
+	}
 	traceChain, ok := btrace.GetMDFromCtx(ctx)
 	if ok {
 		tmp = tmp.With(zap.Array("trace", traceChain.Get()))
+		if dropped := traceChain.Dropped(); dropped > 0 {
+			tmp = tmp.With(zap.Int("trace_dropped", dropped))
+		}
 	}
 	// A new pointer object must be used to store the engine
 	// to prevent polluting the original engine
@@ -63,6 +71,27 @@ func (d *defaultLogger) WithContext(ctx context.Context) Logger {
 	}
 }
 
+// spanIDsFromCtx get the trace-id and span-id in context.
+// the peer span carried by a plain context.Context (see btrace.GetPeerSpanContext) is preferred,
+// such as an OTel span started by other instrumented libraries after the btrace span.
+// a bcontext.Context keeps the current span in btrace, the peer span is the fallback.
+func spanIDsFromCtx(ctx context.Context) (traceID, spanID string) {
+	psc, peer := btrace.GetPeerSpanContext(ctx)
+	if _, ok := ctx.(bcontext.Context); !ok && peer {
+		return psc.TraceID.String(), psc.SpanID.String()
+	}
+	if traceID = btrace.GetTraceID(ctx); len(traceID) > 0 {
+		if sc, ok := btrace.GetSpanContext(ctx); ok && sc.IsValid() {
+			spanID = sc.SpanID.String()
+		}
+		return
+	}
+	if peer {
+		return psc.TraceID.String(), psc.SpanID.String()
+	}
+	return
+}
+
 // WithError parse the built-in information of the error into log.
 func (d *defaultLogger) WithError(err error) Logger {
 	if err == nil {
@@ -92,7 +121,7 @@ func (d *defaultLogger) WithStack(source any) Logger {
 	case berror.Error:
 		stack = tmp.Stack()
 	default:
-		stack = bstack.TakeStack(1, bstack.StacktraceMax)
+		stack = bstack.TakeStackWithOptions(1, bstack.GetOptions())
 	}
 	// A new pointer object must be used to store the engine
 	// to prevent polluting the original engine
@@ -0,0 +1,48 @@
+// Package carrier propagate the W3C trace context through the headers of the rabbitmq messages.
+package carrier
+
+import (
+	"context"
+
+	"github.com/lamber92/go-brick/btrace"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/spf13/cast"
+)
+
+// Table adapt amqp.Table to btrace.Carrier
+type Table amqp.Table
+
+func (c Table) Get(key string) string {
+	v, ok := c[key]
+	if !ok {
+		return ""
+	}
+	switch tmp := v.(type) {
+	case []byte:
+		return string(tmp)
+	default:
+		return cast.ToString(tmp)
+	}
+}
+
+func (c Table) Set(key string, value string) {
+	c[key] = value
+}
+
+// Inject write the W3C trace context of ctx into the headers.
+// returns the headers, which are created if nil.
+func Inject(ctx context.Context, headers amqp.Table) amqp.Table {
+	if headers == nil {
+		headers = amqp.Table{}
+	}
+	btrace.Inject(ctx, Table(headers))
+	return headers
+}
+
+// Extract read the W3C trace context from the headers into ctx
+func Extract(ctx context.Context, headers amqp.Table) context.Context {
+	if headers == nil {
+		return ctx
+	}
+	return btrace.Extract(ctx, Table(headers))
+}
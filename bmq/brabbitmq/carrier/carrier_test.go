@@ -0,0 +1,38 @@
+package carrier_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/bmq/brabbitmq/carrier"
+	"github.com/lamber92/go-brick/btrace"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundTrip(t *testing.T) {
+	_, span := btrace.StartSpan(bcontext.New(), "publish")
+	defer span.End()
+	ctx := btrace.SetSpanContext(context.Background(), span.SpanContext())
+
+	headers := carrier.Inject(ctx, nil)
+	assert.NotEmpty(t, headers[btrace.HeaderTraceParent])
+
+	// the headers may be delivered as bytes
+	headers[btrace.HeaderTraceParent] = []byte(headers[btrace.HeaderTraceParent].(string))
+	out := carrier.Extract(bcontext.New(), headers)
+	sc, ok := btrace.GetSpanContext(out)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, span.SpanContext().TraceID, sc.TraceID)
+	// the span started by the consumer is a child of the publish span
+	_, consume := btrace.StartSpan(out, "consume")
+	assert.Equal(t, span.SpanContext().SpanID, consume.SpanContext().ParentSpanID)
+	consume.End()
+
+	// no trace context
+	plain := bcontext.New()
+	assert.Equal(t, plain, carrier.Extract(plain, nil))
+	_, ok = btrace.GetSpanContext(carrier.Extract(plain, amqp.Table{}))
+	assert.Equal(t, false, ok)
+}
@@ -6,10 +6,12 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/lamber92/go-brick/bcontext"
 	"github.com/lamber92/go-brick/berror"
 	"github.com/lamber92/go-brick/berror/bstatus"
 	"github.com/lamber92/go-brick/blog/logger"
 	"github.com/lamber92/go-brick/bmq/brabbitmq/config"
+	"github.com/lamber92/go-brick/btrace"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -174,17 +176,21 @@ func (c *Consumer) Work(f Handler) error {
 
 func (c *Consumer) handlerTrace(ctx *Context, ds []*amqp.Delivery, idx uint) error {
 	if c.trace {
-		now := time.Now()
+		sctx, span := btrace.StartSpan(ctx.Context, string(traceModule))
+		if bctx, ok := sctx.(bcontext.Context); ok {
+			ctx.Context = bctx
+		}
 		err := ctx.Next(ds, idx)
-		c.traceFunc(ctx, err, ds, time.Since(now), idx)
+		c.traceFunc(ctx, err, ds, span.Duration(), idx)
+		span.End()
 		return err
 	}
 	return ctx.Next(ds, idx)
 }
 
 func (c *Consumer) startWork() {
+	c.exitWorkerDone.Add(1)
 	go func() {
-		c.exitWorkerDone.Add(1)
 		timer := time.NewTimer(0)
 	LOOP:
 		for {
@@ -248,7 +254,7 @@ func (c *Consumer) startWork() {
 // strategies for handling messages after they are successfully consumed or failed to be consumed
 // returning non-nil indicates that the outer loop needs to be interrupted
 func (c *Consumer) handleMessage(ds []*amqp.Delivery) error {
-	err := newContext(c.handlerChainReadOnly).Handle(ds, c.id)
+	err := newDeliveryContext(c.handlerChainReadOnly, ds).Handle(ds, c.id)
 	if err == nil {
 		// running to this point indicates that
 		// the business side consumes successfully
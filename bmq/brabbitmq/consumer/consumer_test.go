@@ -4,8 +4,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/lamber92/go-brick/bcontext"
 	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/bmq/brabbitmq/carrier"
 	"github.com/lamber92/go-brick/bmq/brabbitmq/config"
+	"github.com/lamber92/go-brick/btrace"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -186,3 +189,18 @@ func _testNewConsumer1(prefetchCount uint32) (*Consumer, error) {
 		Key: key1,
 	}, 1)
 }
+
+func TestNewDeliveryContext(t *testing.T) {
+	_, span := btrace.StartSpan(bcontext.New(), "publish")
+	defer span.End()
+	headers := carrier.Inject(btrace.SetSpanContext(bcontext.New(), span.SpanContext()), nil)
+
+	ctx := newDeliveryContext(nil, []*amqp.Delivery{{Headers: headers}, {}})
+	sc, ok := btrace.GetSpanContext(ctx)
+	if !ok || sc.TraceID != span.SpanContext().TraceID {
+		t.Fatalf("the trace is not continued: %v", sc)
+	}
+	if _, ok = btrace.GetSpanContext(newDeliveryContext(nil, []*amqp.Delivery{{}})); ok {
+		t.Fatal("unexpected trace context")
+	}
+}
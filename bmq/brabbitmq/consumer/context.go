@@ -6,6 +6,7 @@ import (
 	"github.com/lamber92/go-brick/bcontext"
 	"github.com/lamber92/go-brick/berror"
 	"github.com/lamber92/go-brick/blog/logger"
+	"github.com/lamber92/go-brick/bmq/brabbitmq/carrier"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -39,6 +40,18 @@ func newContext(chain []Handler, withCtx ...bcontext.Context) *Context {
 	return res
 }
 
+// newDeliveryContext continue the trace of the deliveries, whose trace context is injected by the producer.
+// the deliveries of a batch share a context, the trace context of the first delivery is taken.
+func newDeliveryContext(chain []Handler, ds []*amqp.Delivery) *Context {
+	ctx := bcontext.New()
+	if len(ds) > 0 {
+		if tmp, ok := carrier.Extract(ctx, ds[0].Headers).(bcontext.Context); ok {
+			ctx = tmp
+		}
+	}
+	return newContext(chain, ctx)
+}
+
 func (c *Context) Handle(ds []*amqp.Delivery, index uint) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -1,9 +1,11 @@
 package consumer
 
 import (
+	"net/http"
+
 	"github.com/lamber92/go-brick/berror"
 	"github.com/lamber92/go-brick/berror/bcode"
-	"github.com/lamber92/go-brick/berror/bstatus"
+	"google.golang.org/grpc/codes"
 )
 
 var (
@@ -13,7 +15,10 @@ var (
 )
 
 var (
-	EventAckFail         = berror.New(bstatus.New(EventCodeAckFail, "rabbitmq delivery ACK fail", nil))
-	EventNackFail        = berror.New(bstatus.New(EventCodeNackFail, "rabbitmq delivery NACK fail", nil))
-	EventRetryInfinitely = berror.New(bstatus.New(EventCodeRetryInfinitely, "rabbitmq delivery RETRY infinitely", nil))
+	EventAckFail = berror.New(berror.Define(EventCodeAckFail, "rabbitmq_ack_fail",
+		"rabbitmq delivery ACK fail", bcode.LvCritical, codes.Internal, http.StatusInternalServerError))
+	EventNackFail = berror.New(berror.Define(EventCodeNackFail, "rabbitmq_nack_fail",
+		"rabbitmq delivery NACK fail", bcode.LvCritical, codes.Internal, http.StatusInternalServerError))
+	EventRetryInfinitely = berror.New(berror.Define(EventCodeRetryInfinitely, "rabbitmq_retry_infinitely",
+		"rabbitmq delivery RETRY infinitely", bcode.LvCritical, codes.Internal, http.StatusInternalServerError))
 )
@@ -2,8 +2,6 @@ package consumer
 
 import (
 	"errors"
-	"net"
-	"strings"
 	"time"
 
 	"github.com/lamber92/go-brick/berror"
@@ -40,45 +38,19 @@ func (hdr *RetryHandler) Enable() bool {
 
 // InfiniteRetry whether to DO-NOT limit the number of retries
 func (hdr *RetryHandler) InfiniteRetry(err error) bool {
-	var (
-		infinitely = false
-		opError    *net.OpError
-		innError   berror.Error
-	)
-	switch {
 	// there are two trigger scenarios for infinite retry:
 	// 1. controlled by the business side
-	// 2. for errors related to network connection types
-	case errors.As(err, &opError):
-		infinitely = true
-		logger.Infra.WithError(err).Warn(hdr.buildLogPrefix() + "internal network error. triggers continuous retry...")
-	case errors.As(err, &innError):
-		if innError.Status().Code() == EventCodeRetryInfinitely {
-			infinitely = true
-		} else {
-			// try to get the original error
-			innerErr := innError.Cause()
-			if innerErr == nil {
-				// if the original error type cannot be obtained,
-				// give up and retry infinitely.
-				break
-			}
-			var opError *net.OpError
-			if errors.As(innerErr, &opError) {
-				infinitely = true
-			}
-		}
-	default:
-		// this is a last resort.
-		// when the error content contains the following content, retry infinitely:
-		// - bad connection
-		// - connection refused
-		// - invalid connection
-		if strings.Contains(err.Error(), "connection") {
-			infinitely = true
-		}
+	// 2. for the errors marked retryable or caused by the network or the broker, see berror.IsTransient.
+	// the errors that are retryable by code only are retried within the limit,
+	// so that a bad message answered with a timeout code is not redelivered forever.
+	if errors.Is(err, EventRetryInfinitely) {
+		return true
+	}
+	if berror.IsTransient(err) {
+		logger.Infra.WithError(err).Warn(hdr.buildLogPrefix() + "transient error. triggers continuous retry...")
+		return true
 	}
-	return infinitely
+	return false
 }
 
 // ExceededLimit check whether the max retried times has been exceeded
@@ -4,41 +4,34 @@ import (
 	"time"
 
 	"github.com/lamber92/go-brick/btrace"
-	"github.com/lamber92/go-brick/internal/bufferpool"
-	"github.com/lamber92/go-brick/internal/json"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap/zapcore"
 )
 
+const (
+	traceModule btrace.Module = "rabbitmq-consumer"
+)
+
+// TraceFunc tracking information callback.
+// it is called before the consuming span ends, and the span can be taken from ctx by btrace.SpanFromCtx.
 type TraceFunc func(ctx *Context, err error, ds []*amqp.Delivery, since time.Duration, idx uint)
 
 func defaultTraceFunc(ctx *Context, err error, ds []*amqp.Delivery, since time.Duration, idx uint) {
-	btrace.AppendMDIntoCtx(ctx, newTraceMD(err, ds, since.Milliseconds(), idx))
-}
-
-func newTraceMD(err error, ds []*amqp.Delivery, cost int64, idx uint) btrace.Metadata {
-	res := &trace{
-		module:     "rabbitmq-consumer",
-		cost:       cost,
-		err:        err,
-		consumerId: idx,
+	span, ok := btrace.SpanFromCtx(ctx)
+	if !ok {
+		return
 	}
-	res.parseDeliveryList(ds)
-	return res
+	span.SetAttributes(
+		btrace.Attr("messages", newTraceItemList(ds)),
+		btrace.Attr("consumer_id", idx),
+	)
+	span.SetError(err)
 }
 
-type trace struct {
-	module     btrace.Module
-	messages   traceItemList
-	consumerId uint
-	cost       int64
-	err        error
-}
-
-func (t *trace) parseDeliveryList(ds []*amqp.Delivery) {
-	t.messages = make(traceItemList, 0, len(ds))
+func newTraceItemList(ds []*amqp.Delivery) traceItemList {
+	out := make(traceItemList, 0, len(ds))
 	for _, d := range ds {
-		t.messages = append(t.messages, traceItem{
+		out = append(out, traceItem{
 			MessageId:   d.MessageId,
 			Timestamp:   d.Timestamp,
 			Type:        d.Type,
@@ -48,48 +41,9 @@ func (t *trace) parseDeliveryList(ds []*amqp.Delivery) {
 			Body:        d.Body,
 		})
 	}
-}
-
-func (t *trace) Module() btrace.Module {
-	return t.module
-}
-
-func (t *trace) String() string {
-	buff := bufferpool.Get()
-	buff.AppendString("module: ")
-	buff.AppendString(string(t.module))
-	buff.AppendString(" | messages: ")
-	tmp, _ := json.MarshalToString(t.messages)
-	buff.AppendString(tmp)
-	buff.AppendString(" | consumer_id: ")
-	buff.AppendUint(uint64(t.consumerId))
-	buff.AppendString(" | cost: ")
-	buff.AppendInt(t.cost)
-	if t.err != nil {
-		buff.AppendString(" | err: ")
-		buff.AppendString(t.err.Error())
-	}
-	out := buff.String()
-	buff.Free()
 	return out
 }
 
-func (t *trace) MarshalLogObject(enc zapcore.ObjectEncoder) error {
-	if t == nil {
-		return nil
-	}
-	enc.AddString("module", string(t.module))
-	_ = enc.AddArray("messages", t.messages)
-	enc.AddUint("consumer_id", t.consumerId)
-	enc.AddInt64("cost", t.cost)
-	if t.err == nil {
-		enc.AddString("err", "")
-	} else {
-		enc.AddString("err", t.err.Error())
-	}
-	return nil
-}
-
 type traceItemList []traceItem
 
 func (l traceItemList) MarshalLogArray(enc zapcore.ArrayEncoder) error {
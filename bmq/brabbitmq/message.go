@@ -4,11 +4,13 @@ import (
 	"context"
 	"time"
 
+	"github.com/lamber92/go-brick/bmq/brabbitmq/carrier"
 	"github.com/lamber92/go-brick/btrace"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-// BuildTextMsg4Publish build a simple rabbitmq-producer-message of text
+// BuildTextMsg4Publish build a simple rabbitmq-producer-message of text.
+// the trace context is injected into the headers by producer.Producer.Publish.
 func BuildTextMsg4Publish(ctx context.Context, body []byte, persistent bool, priorities ...uint8) *amqp.Publishing {
 	var (
 		priority     uint8 = 0
@@ -21,7 +23,6 @@ func BuildTextMsg4Publish(ctx context.Context, body []byte, persistent bool, pri
 		deliveryMode = 2
 	}
 	return &amqp.Publishing{
-		Headers:         amqp.Table{},
 		ContentType:     "text/plain",
 		ContentEncoding: "",
 		Body:            body,
@@ -31,3 +32,13 @@ func BuildTextMsg4Publish(ctx context.Context, body []byte, persistent bool, pri
 		Timestamp:       time.Now(),
 	}
 }
+
+// InjectTraceHeaders write the W3C trace context of ctx into the message headers
+func InjectTraceHeaders(ctx context.Context, headers amqp.Table) {
+	carrier.Inject(ctx, headers)
+}
+
+// ExtractTraceHeaders read the W3C trace context from the message headers into ctx
+func ExtractTraceHeaders(ctx context.Context, headers amqp.Table) context.Context {
+	return carrier.Extract(ctx, headers)
+}
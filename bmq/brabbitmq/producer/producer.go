@@ -9,7 +9,10 @@ import (
 	"github.com/lamber92/go-brick/berror"
 	"github.com/lamber92/go-brick/berror/bcode"
 	"github.com/lamber92/go-brick/blog/logger"
+	"github.com/lamber92/go-brick/bmq/brabbitmq/carrier"
 	"github.com/lamber92/go-brick/bmq/brabbitmq/config"
+	"github.com/lamber92/go-brick/bstack"
+	"github.com/lamber92/go-brick/btrace"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -48,6 +51,9 @@ type Producer struct {
 	// before the message is pushed successfully.
 	// default is false
 	strictlyConfirm bool
+	// dump the goroutines when a publish action is blocked longer than it,
+	// disabled when set to 0
+	publishWatchdog time.Duration
 	//
 	id uint
 	//
@@ -117,20 +123,32 @@ func (p *Producer) EnableStrictlyConfirm() *Producer {
 	return p
 }
 
+// SetPublishWatchdog dump the goroutines into log when a publish action is blocked longer than the timeout,
+// which helps to find where it is stuck.
+func (p *Producer) SetPublishWatchdog(timeout time.Duration) *Producer {
+	p.publishWatchdog = timeout
+	return p
+}
+
 // GetKey get producer config key
 func (p *Producer) GetKey() string {
 	return p.client.conf.Key
 }
 
-// Publish push one message to rabbitmq server
+// Publish push one message to rabbitmq server.
+// the W3C trace context is injected into the headers of data, so that the consumer continues the trace.
 func (p *Producer) Publish(ctx context.Context, data *amqp.Publishing) (err error) {
 	var times uint = 0
 	if p.trace {
-		begin := time.Now()
+		var span btrace.Span
+		ctx, span = btrace.StartSpan(ctx, string(traceModule))
 		defer func() {
-			p.traceFunc(ctx, err, data, time.Since(begin))
+			p.traceFunc(ctx, err, data, span.Duration())
+			span.End()
 		}()
 	}
+	// after the span is started, so that it is the parent of the consumer
+	data.Headers = carrier.Inject(ctx, data.Headers)
 
 	for ; times <= p.maxRetryTimes; times++ {
 		if times > 0 {
@@ -144,6 +162,9 @@ func (p *Producer) Publish(ctx context.Context, data *amqp.Publishing) (err erro
 				With(logger.NewField().String("body", string(data.Body))).
 				With(logger.NewField().String("message_id", data.MessageId)).
 				Warn("push message fail")
+			if !berror.IsRetryable(err) {
+				return
+			}
 			continue
 		}
 		if !p.client.subConf.NoConfirm {
@@ -165,6 +186,9 @@ func (p *Producer) Publish(ctx context.Context, data *amqp.Publishing) (err erro
 					With(logger.NewField().String("body", string(data.Body))).
 					With(logger.NewField().String("message_id", data.MessageId)).
 					Warn("get message confirmation fail")
+				if !berror.IsRetryable(err) {
+					return
+				}
 				continue
 			} else if !confirmation.Ack {
 				logger.Infra.WithError(err).
@@ -196,7 +220,15 @@ func (p *Producer) publish(ctx context.Context, data *amqp.Publishing) (err erro
 		//
 		// Here is a rough way to deal with it for the time being.
 		if p.recovering {
-			return berror.NewClientClose(nil, p.buildLogPrefix()+"client is recovering")
+			// the connection is being re-established, retry later
+			return berror.MarkRetryable(berror.NewClientClose(nil, p.buildLogPrefix()+"client is recovering"))
+		}
+		if p.publishWatchdog > 0 {
+			watchdog := bstack.NewWatchdog(p.publishWatchdog, func(list bstack.GoroutineList) {
+				logger.Infra.With(logger.NewField().Any("goroutines", list.Group())).
+					Errorf(p.buildLogPrefix()+"publish message blocked over %s", p.publishWatchdog)
+			})
+			defer watchdog.Stop()
 		}
 		if err = p.client.channel.PublishWithContext(
 			ctx,
@@ -206,7 +238,14 @@ func (p *Producer) publish(ctx context.Context, data *amqp.Publishing) (err erro
 			false,                       // immediate
 			*data,
 		); err != nil {
-			return berror.Convert(err, p.buildLogPrefix()+"publish message fail | body: "+string(data.Body))
+			// the retryability is decided by the original error,
+			// which may be replaced by the customized mapping of Convert, such as amqp.ErrClosed.
+			retryable := berror.IsRetryable(err)
+			err = berror.Convert(err, p.buildLogPrefix()+"publish message fail | body: "+string(data.Body))
+			if retryable {
+				err = berror.MarkRetryable(err)
+			}
+			return err
 		}
 		return
 	}
@@ -226,7 +265,8 @@ func (p *Producer) getConfirmation() (amqp.Confirmation, error) {
 		return amqp.Confirmation{}, berror.NewGatewayTimeout(nil, p.buildLogPrefix()+"confirm timeout")
 	case info, ok := <-p.client.confirms:
 		if !ok {
-			return amqp.Confirmation{}, berror.NewClientClose(nil, p.buildLogPrefix()+"confirm channel has been close")
+			// the connection is lost, the message can be pushed again after it is recovered
+			return amqp.Confirmation{}, berror.MarkRetryable(berror.NewClientClose(nil, p.buildLogPrefix()+"confirm channel has been close"))
 		}
 		return info, nil
 	}
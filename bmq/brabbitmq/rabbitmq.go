@@ -116,7 +116,7 @@ func CloseConsumer(key string) error {
 			return nil
 		})
 	}
-	if err := eg.Wait(); err != nil {
+	if err := eg.WaitAll(); err != nil {
 		return err
 	}
 	consumerHub.Delete(key)
@@ -139,14 +139,15 @@ func CloseProducer(key string) error {
 	return nil
 }
 
-// Close exit all rabbitmq producers and consumers
+// Close exit all rabbitmq producers and consumers.
+// the instances that fail to close are kept, and all the errors are returned by berror.Join.
 func Close() error {
 	eg, ctx := berrgroup.WithContext(context.Background())
 	defer ctx.Cancel()
 
-	closedKeys := bset.NewSafeSet[string]()
+	closedConsumers := bset.NewSafeSet[string]()
 	consumerHub.Range(func(key, value any) bool {
-		closedKeys.Add(key.(string))
+		closedConsumers.Add(key.(string))
 		tmp, ok := value.([]*consumer.Consumer)
 		if !ok {
 			logger.Infra.Errorf("[rabbitmq-consumer][%s] has invalid instance type", key)
@@ -156,7 +157,7 @@ func Close() error {
 			cons := v
 			eg.Go(func() error {
 				if err := cons.Close(); err != nil {
-					closedKeys.Delete(key.(string))
+					closedConsumers.Delete(key.(string))
 					return err
 				}
 				return nil
@@ -164,13 +165,10 @@ func Close() error {
 		}
 		return true
 	})
-	for _, key := range closedKeys.ToSlice() {
-		consumerHub.Delete(key)
-	}
 
-	closedKeys.Clear()
+	closedProducers := bset.NewSafeSet[string]()
 	producerHub.Range(func(key, value any) bool {
-		closedKeys.Add(key.(string))
+		closedProducers.Add(key.(string))
 		tmp, ok := value.(*producer.Producer)
 		if !ok {
 			logger.Infra.Errorf("[rabbitmq-producer][%s] has invalid instance type", key)
@@ -178,16 +176,20 @@ func Close() error {
 		}
 		eg.Go(func() error {
 			if err := tmp.Close(); err != nil {
-				closedKeys.Delete(key.(string))
+				closedProducers.Delete(key.(string))
 				return err
 			}
 			return nil
 		})
 		return true
 	})
-	for _, key := range closedKeys.ToSlice() {
+
+	err := eg.WaitAll()
+	for _, key := range closedConsumers.ToSlice() {
+		consumerHub.Delete(key)
+	}
+	for _, key := range closedProducers.ToSlice() {
 		producerHub.Delete(key)
 	}
-
-	return eg.Wait()
+	return err
 }
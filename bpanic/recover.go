@@ -15,16 +15,44 @@ import (
 
 const recoverReason = "recover"
 
-var _identifyErr = defaultIdentify
+var (
+	_identifyErr  = defaultIdentify
+	_recoverHooks []func(err error)
+)
 
 func ReplaceRecoverIdentify(f func(r any, hook func(err error))) {
 	_identifyErr = f
 }
 
+// RegisterRecoverHook add a hook observing every error recovered by Recover, before the hook given to Recover.
+// nb. this function is not thread-safe, call it when you initialize the program.
+func RegisterRecoverHook(hook func(err error)) {
+	if hook == nil {
+		return
+	}
+	_recoverHooks = append(_recoverHooks, hook)
+}
+
 // Recover catching and recovering from panics
 func Recover(hook func(error)) {
 	if r := recover(); r != nil {
-		_identifyErr(r, hook)
+		_identifyErr(r, withRecoverHooks(hook))
+	}
+}
+
+// withRecoverHooks chain the registered hooks before hook
+func withRecoverHooks(hook func(error)) func(error) {
+	if len(_recoverHooks) == 0 {
+		return hook
+	}
+	if hook == nil {
+		hook = SimpleHook
+	}
+	return func(err error) {
+		for _, h := range _recoverHooks {
+			h(err)
+		}
+		hook(err)
 	}
 }
 
@@ -0,0 +1,354 @@
+package bstack
+
+import (
+	"bufio"
+	"bytes"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	goroutinePrefix = "goroutine "
+	createdByPrefix = "created by "
+	dumpBufferSize  = 64 << 10
+)
+
+// Goroutine a goroutine parsed from the dump of runtime.Stack
+type Goroutine struct {
+	ID int64 `json:"id"`
+	// State such as 'running', 'chan receive' or 'select'
+	State string `json:"state"`
+	// Wait how long the goroutine has been blocked, the runtime reports it in minutes
+	Wait time.Duration `json:"wait"`
+	// LockedToThread whether the goroutine is locked to its thread
+	LockedToThread bool `json:"locked_to_thread,omitempty"`
+	// Stack from the top to the bottom
+	Stack StackList `json:"stack"`
+	// CreatedBy the go statement that started the goroutine, nil for the main goroutine
+	CreatedBy *Frame `json:"created_by,omitempty"`
+}
+
+func (g *Goroutine) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt64("id", g.ID)
+	enc.AddString("state", g.State)
+	enc.AddDuration("wait", g.Wait)
+	if g.LockedToThread {
+		enc.AddBool("locked_to_thread", true)
+	}
+	if g.CreatedBy != nil {
+		if err := enc.AddObject("created_by", g.CreatedBy); err != nil {
+			return err
+		}
+	}
+	return enc.AddArray("stack", g.Stack)
+}
+
+// GoroutineList goroutines parsed from a dump
+type GoroutineList []*Goroutine
+
+func (l GoroutineList) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, g := range l {
+		if err := enc.AppendObject(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GoroutineGroup the goroutines that share an identical stack
+type GoroutineGroup struct {
+	Count int `json:"count"`
+	// IDs of the goroutines in ascending order
+	IDs []int64 `json:"ids"`
+	// States the distinct states of the goroutines
+	States []string `json:"states"`
+	// MaxWait the longest wait of the goroutines
+	MaxWait   time.Duration `json:"max_wait"`
+	Stack     StackList     `json:"stack"`
+	CreatedBy *Frame        `json:"created_by,omitempty"`
+}
+
+func (g *GoroutineGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("count", g.Count)
+	enc.AddDuration("max_wait", g.MaxWait)
+	if err := enc.AddArray("ids", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, id := range g.IDs {
+			enc.AppendInt64(id)
+		}
+		return nil
+	})); err != nil {
+		return err
+	}
+	if err := enc.AddArray("states", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, s := range g.States {
+			enc.AppendString(s)
+		}
+		return nil
+	})); err != nil {
+		return err
+	}
+	if g.CreatedBy != nil {
+		if err := enc.AddObject("created_by", g.CreatedBy); err != nil {
+			return err
+		}
+	}
+	return enc.AddArray("stack", g.Stack)
+}
+
+// GoroutineGroupList groups of goroutines
+type GoroutineGroupList []*GoroutineGroup
+
+func (l GoroutineGroupList) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, g := range l {
+		if err := enc.AppendObject(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Group the goroutines by identical stacks (function, file and line of every frame, plus the creator).
+// the groups are ordered by the number of goroutines, the largest first,
+// which usually points at the place where the goroutines are piling up.
+func (l GoroutineList) Group() GoroutineGroupList {
+	var (
+		out   = make(GoroutineGroupList, 0)
+		index = make(map[string]*GoroutineGroup)
+	)
+	for _, g := range l {
+		key := stackKey(g)
+		group, ok := index[key]
+		if !ok {
+			group = &GoroutineGroup{Stack: g.Stack, CreatedBy: g.CreatedBy}
+			index[key] = group
+			out = append(out, group)
+		}
+		group.Count++
+		group.IDs = append(group.IDs, g.ID)
+		if g.Wait > group.MaxWait {
+			group.MaxWait = g.Wait
+		}
+		if !containsString(group.States, g.State) {
+			group.States = append(group.States, g.State)
+		}
+	}
+	for _, group := range out {
+		sort.Slice(group.IDs, func(i, j int) bool { return group.IDs[i] < group.IDs[j] })
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Count > out[j].Count })
+	return out
+}
+
+func stackKey(g *Goroutine) string {
+	var sb strings.Builder
+	for _, f := range g.Stack.Frames() {
+		sb.WriteString(f.Func)
+		sb.WriteByte('@')
+		sb.WriteString(f.File)
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Itoa(f.Line))
+		sb.WriteByte('\n')
+	}
+	if g.CreatedBy != nil {
+		sb.WriteString(createdByPrefix)
+		sb.WriteString(g.CreatedBy.Func)
+		sb.WriteByte('@')
+		sb.WriteString(g.CreatedBy.File)
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Itoa(g.CreatedBy.Line))
+	}
+	return sb.String()
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// DumpGoroutines capture the stacks of all the goroutines and parse them.
+// nb. it stops the world while capturing, do not call it on a hot path.
+func DumpGoroutines() GoroutineList {
+	buf := make([]byte, dumpBufferSize)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return ParseGoroutines(buf[:n])
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
+
+// ParseGoroutines parse the text produced by runtime.Stack, debug.Stack or a panic.
+// the lines that cannot be understood are skipped.
+func ParseGoroutines(dump []byte) GoroutineList {
+	var (
+		out     = make(GoroutineList, 0)
+		current *Goroutine
+		frames  []*Frame
+		pending *Frame // the function line waiting for its file line
+		created bool   // the pending frame is the creator
+	)
+	flush := func() {
+		if current != nil {
+			current.Stack = NewStackList(frames)
+			out = append(out, current)
+		}
+		current, frames, pending, created = nil, nil, nil, false
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(dump))
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case len(strings.TrimSpace(line)) == 0:
+			flush()
+		case strings.HasPrefix(line, goroutinePrefix):
+			flush()
+			current = parseGoroutineHeader(line)
+		case current == nil:
+			// not in a goroutine block
+		case strings.HasPrefix(line, "\t"):
+			if pending == nil {
+				continue
+			}
+			pending.File, pending.Line = parseFileLine(line)
+			if created {
+				current.CreatedBy = pending
+			} else {
+				frames = append(frames, pending)
+			}
+			pending, created = nil, false
+		case strings.HasPrefix(line, createdByPrefix):
+			pending, created = &Frame{Func: parseCreatedBy(line)}, true
+		case strings.HasPrefix(line, "..."):
+			// ...additional frames elided...
+		default:
+			pending, created = &Frame{Func: parseFunc(line)}, false
+		}
+	}
+	flush()
+	return out
+}
+
+// parseGoroutineHeader such as 'goroutine 1 [chan receive, 3 minutes, locked to thread]:'
+func parseGoroutineHeader(line string) *Goroutine {
+	g := &Goroutine{}
+	rest := strings.TrimPrefix(line, goroutinePrefix)
+	idx := strings.IndexByte(rest, ' ')
+	if idx < 0 {
+		return g
+	}
+	g.ID, _ = strconv.ParseInt(rest[:idx], 10, 64)
+	start, end := strings.IndexByte(rest, '['), strings.LastIndexByte(rest, ']')
+	if start < 0 || end < start {
+		return g
+	}
+	for i, part := range strings.Split(rest[start+1:end], ", ") {
+		switch {
+		case i == 0:
+			g.State = part
+		case part == "locked to thread":
+			g.LockedToThread = true
+		case strings.HasSuffix(part, " minutes"):
+			if m, err := strconv.Atoi(strings.TrimSuffix(part, " minutes")); err == nil {
+				g.Wait = time.Duration(m) * time.Minute
+			}
+		}
+	}
+	return g
+}
+
+// parseFunc such as 'main.(*T).Run(0xc000010000, {0x1, 0x2})', the arguments are dropped
+func parseFunc(line string) string {
+	if strings.HasSuffix(line, ")") {
+		if idx := strings.LastIndexByte(line, '('); idx > 0 {
+			return line[:idx]
+		}
+	}
+	return line
+}
+
+// parseCreatedBy such as 'created by testing.(*T).Run in goroutine 1'
+func parseCreatedBy(line string) string {
+	line = strings.TrimPrefix(line, createdByPrefix)
+	if idx := strings.Index(line, " in goroutine "); idx >= 0 {
+		line = line[:idx]
+	}
+	return line
+}
+
+// parseFileLine such as '\t/usr/local/go/src/testing/testing.go:2258 +0x4d4'
+func parseFileLine(line string) (string, int) {
+	line = strings.TrimSpace(line)
+	if idx := strings.LastIndex(line, " +0x"); idx >= 0 {
+		line = line[:idx]
+	}
+	idx := strings.LastIndexByte(line, ':')
+	if idx < 0 {
+		return line, 0
+	}
+	no, err := strconv.Atoi(line[idx+1:])
+	if err != nil {
+		return line, 0
+	}
+	return line[:idx], no
+}
+
+// =======================================
+// ------------- Watchdog ----------------
+// =======================================
+
+// Watchdog dump the goroutines when it is not stopped or kicked within the timeout,
+// such as a call that may block forever.
+type Watchdog struct {
+	timeout   time.Duration
+	onTimeout func(GoroutineList)
+	timer     *time.Timer
+	fired     bool
+	sync.Mutex
+}
+
+// NewWatchdog start a watchdog.
+// @onTimeout: receives the dump, it runs on its own goroutine.
+func NewWatchdog(timeout time.Duration, onTimeout func(GoroutineList)) *Watchdog {
+	w := &Watchdog{timeout: timeout, onTimeout: onTimeout}
+	w.timer = time.AfterFunc(timeout, w.fire)
+	return w
+}
+
+func (w *Watchdog) fire() {
+	w.Lock()
+	w.fired = true
+	w.Unlock()
+	w.onTimeout(DumpGoroutines())
+}
+
+// Kick postpone the timeout, returns 'false' if it has fired or been stopped
+func (w *Watchdog) Kick() bool {
+	w.Lock()
+	defer w.Unlock()
+	if w.fired || !w.timer.Stop() {
+		return false
+	}
+	w.timer.Reset(w.timeout)
+	return true
+}
+
+// Stop the watchdog, returns 'true' if it has fired
+func (w *Watchdog) Stop() (fired bool) {
+	w.Lock()
+	defer w.Unlock()
+	w.timer.Stop()
+	return w.fired
+}
@@ -0,0 +1,114 @@
+package bstack_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lamber92/go-brick/bstack"
+	"github.com/lamber92/go-brick/internal/json"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+const testDump = `goroutine 8 [running]:
+main.work({0xddb478, 0x295c7f61da10})
+	/app/main.go:20 +0x232
+created by main.main in goroutine 1
+	/app/main.go:10 +0x4d4
+
+goroutine 1 [chan receive, 3 minutes]:
+main.main()
+	/app/main.go:12 +0x25
+
+goroutine 21 [chan send, 5 minutes, locked to thread]:
+github.com/lamber92/go-brick/bmq/brabbitmq/producer.(*Producer).publish(0xc000010000)
+	/app/producer.go:206 +0x1f
+...additional frames elided...
+created by main.main in goroutine 1
+	/app/main.go:11 +0x4d4
+
+goroutine 22 [chan send]:
+github.com/lamber92/go-brick/bmq/brabbitmq/producer.(*Producer).publish(0xc000010008)
+	/app/producer.go:206 +0x1f
+created by main.main in goroutine 1
+	/app/main.go:11 +0x4d4
+`
+
+func TestParseGoroutines(t *testing.T) {
+	list := bstack.ParseGoroutines([]byte(testDump))
+	assert.Equal(t, 4, len(list))
+
+	g := list[0]
+	assert.Equal(t, int64(8), g.ID)
+	assert.Equal(t, "running", g.State)
+	assert.Equal(t, []*bstack.Frame{{Func: "main.work", File: "/app/main.go", Line: 20}}, g.Stack.Frames())
+	assert.Equal(t, &bstack.Frame{Func: "main.main", File: "/app/main.go", Line: 10}, g.CreatedBy)
+
+	assert.Equal(t, 3*time.Minute, list[1].Wait)
+	assert.Nil(t, list[1].CreatedBy)
+
+	g = list[2]
+	assert.Equal(t, "chan send", g.State)
+	assert.Equal(t, 5*time.Minute, g.Wait)
+	assert.Equal(t, true, g.LockedToThread)
+	assert.Equal(t, "github.com/lamber92/go-brick/bmq/brabbitmq/producer.(*Producer).publish", g.Stack.Frames()[0].Func)
+
+	groups := list.Group()
+	assert.Equal(t, 3, len(groups))
+	assert.Equal(t, 2, groups[0].Count)
+	assert.Equal(t, []int64{21, 22}, groups[0].IDs)
+	assert.Equal(t, []string{"chan send"}, groups[0].States)
+	assert.Equal(t, 5*time.Minute, groups[0].MaxWait)
+
+	out, err := json.MarshalToString(groups[0])
+	assert.Nil(t, err)
+	assert.Contains(t, out, `"stack":[{"func":"github.com/lamber92/go-brick/bmq/brabbitmq/producer.(*Producer).publish","file":"/app/producer.go","line":206}]`)
+
+	enc := zapcore.NewMapObjectEncoder()
+	assert.Nil(t, enc.AddArray("goroutines", list))
+	assert.Nil(t, enc.AddArray("groups", groups))
+	assert.Equal(t, 4, len(enc.Fields["goroutines"].([]any)))
+}
+
+func TestDumpGoroutines(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	for i := 0; i < 3; i++ {
+		go func() { <-block }()
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	list := bstack.DumpGoroutines()
+	assert.GreaterOrEqual(t, len(list), 4)
+	found := false
+	for _, group := range list.Group() {
+		if group.Count == 3 && group.States[0] == "chan receive" {
+			found = true
+			assert.True(t, strings.HasPrefix(group.Stack.Frames()[0].Func, "github.com/lamber92/go-brick/bstack_test.TestDumpGoroutines"))
+		}
+	}
+	assert.Equal(t, true, found)
+}
+
+func TestWatchdog(t *testing.T) {
+	dumped := make(chan bstack.GoroutineList, 1)
+	w := bstack.NewWatchdog(20*time.Millisecond, func(l bstack.GoroutineList) { dumped <- l })
+	select {
+	case l := <-dumped:
+		assert.NotEmpty(t, l)
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not fire")
+	}
+	assert.Equal(t, true, w.Stop())
+	assert.Equal(t, false, w.Kick())
+
+	w = bstack.NewWatchdog(50*time.Millisecond, func(bstack.GoroutineList) { dumped <- nil })
+	assert.Equal(t, true, w.Kick())
+	assert.Equal(t, false, w.Stop())
+	select {
+	case <-dumped:
+		t.Fatal("stopped watchdog fired")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
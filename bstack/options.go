@@ -0,0 +1,136 @@
+package bstack
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// PathMode specifies how the file path of a frame is printed
+type PathMode int
+
+const (
+	// PathFull keep the absolute path of the build machine
+	PathFull PathMode = iota
+	// PathTrimmed keep only the leaf directory name and file name, such as 'bstack/stacktrace.go'
+	PathTrimmed
+	// PathRelative keep the path relative to the project, GOROOT or the module cache.
+	// see stackFormatter.RelativePath
+	PathRelative
+)
+
+const (
+	modCacheDir = "/pkg/mod/"
+	vendorDir   = "/vendor/"
+)
+
+// FrameFilter report whether the frame should be kept
+type FrameFilter func(frame runtime.Frame) bool
+
+// Options of stack capture
+type Options struct {
+	// Depth the maximum number of frames kept after filtering,
+	// StacktraceFull for unlimited, defaults to StacktraceMax
+	Depth StacktraceDepth
+	// Filters a frame is kept only if all the filters keep it
+	Filters []FrameFilter
+	// PathMode how the file path is printed, defaults to PathFull
+	PathMode PathMode
+	// PathPrefix the root of the project that PathRelative trims, such as '/home/ci/workspace/project/'
+	PathPrefix string
+}
+
+func (o *Options) depth() StacktraceDepth {
+	if o.Depth == 0 {
+		return StacktraceMax
+	}
+	return o.Depth
+}
+
+func (o *Options) keep(frame runtime.Frame) bool {
+	for _, f := range o.Filters {
+		if !f(frame) {
+			return false
+		}
+	}
+	return true
+}
+
+var globalOptions = Options{Depth: StacktraceMax}
+
+// ReplaceOptions overrides the global options used by TakeStack and the stacks taken by berror and blog.
+// nb. this function is not thread-safe, call it when you initialize the program.
+func ReplaceOptions(opts Options) {
+	globalOptions = opts
+}
+
+// GetOptions return the global options.
+// modify the returned copy to take a stack with different options per call.
+func GetOptions() Options {
+	return globalOptions
+}
+
+// =======================================
+// ------ Built-in FrameFilter IMPL ------
+// =======================================
+
+// DropRuntime drop the frames of the go runtime
+func DropRuntime() FrameFilter {
+	return func(frame runtime.Frame) bool {
+		return !strings.HasPrefix(frame.Function, "runtime.")
+	}
+}
+
+// DropTesting drop the frames of the testing package
+func DropTesting() FrameFilter {
+	return func(frame runtime.Frame) bool {
+		return !strings.HasPrefix(frame.Function, "testing.")
+	}
+}
+
+// DropVendored drop the frames of the vendored packages
+func DropVendored() FrameFilter {
+	return func(frame runtime.Frame) bool {
+		return !strings.Contains(frame.File, vendorDir)
+	}
+}
+
+// DropThirdParty drop the frames of the dependencies, either vendored or in the module cache
+func DropThirdParty() FrameFilter {
+	return func(frame runtime.Frame) bool {
+		return !strings.Contains(frame.File, vendorDir) && !strings.Contains(frame.File, modCacheDir)
+	}
+}
+
+// KeepModule keep only the frames whose function is under one of the module prefixes,
+// such as 'github.com/lamber92/go-brick'
+func KeepModule(prefixes ...string) FrameFilter {
+	return func(frame runtime.Frame) bool {
+		for _, p := range prefixes {
+			if strings.HasPrefix(frame.Function, p) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+var (
+	goRootOnce sync.Once
+	goRoot     string
+)
+
+func goRootSrc() string {
+	goRootOnce.Do(func() {
+		//nolint:staticcheck // only used to shorten the paths, an empty one is fine
+		if root := runtime.GOROOT(); len(root) > 0 {
+			goRoot = strings.TrimRight(strings.ReplaceAll(root, "\\", "/"), "/") + "/src/"
+		}
+	})
+	return goRoot
+}
+
+// isEntryFrame report whether the frame is the bottom of every goroutine
+func isEntryFrame(frame runtime.Frame) bool {
+	return frame.Function == "runtime.main" || frame.Function == "runtime.goexit"
+}
@@ -1,6 +1,8 @@
 package bstack
 
 import (
+	"fmt"
+	"hash/fnv"
 	"runtime"
 	"strconv"
 	"strings"
@@ -23,8 +25,7 @@ var _stacktracePool = sync.Pool{
 }
 
 type stacktrace struct {
-	pcs    []uintptr // program counters; always a subslice of storage
-	frames *runtime.Frames
+	pcs []uintptr // program counters; always a subslice of storage
 
 	// The size of pcs varies depending on requirements:
 	// it will be one if the only the first frame was requested,
@@ -37,15 +38,17 @@ type stacktrace struct {
 }
 
 // StacktraceDepth specifies how deep of a stack trace should be captured.
+// any positive value captures at most that many frames.
 type StacktraceDepth int
 
 const (
 	// StacktraceFull captures the entire call stack, allocating more
 	// bstorage for it if needed.
-	StacktraceFull = -1
+	StacktraceFull StacktraceDepth = -1
 	// StacktraceFirst captures only the first frame.
 	StacktraceFirst StacktraceDepth = 1
 	// StacktraceMax captures only the first ten frames.
+	// it is the default depth of Options.
 	StacktraceMax StacktraceDepth = 10
 )
 
@@ -57,17 +60,17 @@ const (
 func captureStacktrace(skip int, depth StacktraceDepth) *stacktrace {
 	stack := _stacktracePool.Get().(*stacktrace)
 
-	switch depth {
-	case StacktraceFirst:
-		stack.pcs = stack.storage[:1]
-	case StacktraceFull:
+	full := depth <= 0
+	if full {
 		stack.pcs = stack.storage
-	case StacktraceMax:
-		if len(stack.storage) > int(StacktraceMax) {
-			stack.pcs = stack.storage[:StacktraceMax]
-		} else {
-			stack.pcs = stack.storage
+	} else {
+		// one more frame, so that the trailing runtime.main/runtime.goexit
+		// frame does not take the place of a real one
+		size := int(depth) + 1
+		if len(stack.storage) < size {
+			stack.storage = make([]uintptr, size)
 		}
+		stack.pcs = stack.storage[:size]
 	}
 
 	// Unlike other "skip"-based APIs, skip=0 identifies runtime.Callers
@@ -80,7 +83,7 @@ func captureStacktrace(skip int, depth StacktraceDepth) *stacktrace {
 	// runtime.Callers truncates the recorded stacktrace if there is no
 	// room in the provided slice. For the full stack trace, keep expanding
 	// bstorage until there are fewer frames than there is room.
-	if depth == StacktraceFull {
+	if full {
 		pcs := stack.pcs
 		for numFrames == len(pcs) {
 			pcs = make([]uintptr, len(pcs)*2)
@@ -96,75 +99,110 @@ func captureStacktrace(skip int, depth StacktraceDepth) *stacktrace {
 		stack.pcs = stack.pcs[:numFrames]
 	}
 
-	stack.frames = runtime.CallersFrames(stack.pcs)
 	return stack
 }
 
 // Free releases resources associated with this stacktrace
 // and returns it back to the pool.
 func (st *stacktrace) Free() {
-	st.frames = nil
 	st.pcs = nil
 	_stacktracePool.Put(st)
 }
 
-// Count reports the total number of frames in this stacktrace.
-// Count DOES NOT change as Next is called.
+// Count reports the total number of program counters in this stacktrace.
 func (st *stacktrace) Count() int {
 	return len(st.pcs)
 }
 
-// Next returns the next frame in the stack trace,
-// and a boolean indicating whether there are more after it.
-func (st *stacktrace) Next() (_ runtime.Frame, more bool) {
-	return st.frames.Next()
+// TakeStack capture the stack of the caller with the global options, except for the depth.
+// skip=0 identifies the caller of TakeStack.
+func TakeStack(skip int, depth StacktraceDepth) StackList {
+	opts := GetOptions()
+	opts.Depth = depth
+	return TakeStackWithOptions(skip+1, opts)
 }
 
-func TakeStack(skip int, depth StacktraceDepth) StackList {
-	stack := captureStacktrace(skip+1, depth)
+// TakeStackWithOptions capture the stack of the caller with the given options.
+// skip=0 identifies the caller of TakeStackWithOptions.
+//
+// nb. only the program counters are kept,
+// they are symbolized when the stack is printed.
+func TakeStackWithOptions(skip int, opts Options) StackList {
+	capture := opts.depth()
+	if len(opts.Filters) > 0 {
+		// the filtered frames do not count, capture all and cut afterwards
+		capture = StacktraceFull
+	}
+	stack := captureStacktrace(skip+1, capture)
 	defer stack.Free()
 
-	stackFmt := newStackFormatter(stack.Count())
-	stackFmt.FormatStack(stack)
-	return stackFmt.Stack()
+	pcs := make([]uintptr, stack.Count())
+	copy(pcs, stack.pcs)
+	return StackList{pcs: pcs, opts: opts}
 }
 
 // stackFormatter formats a stack trace into a readable string representation.
 type stackFormatter struct {
-	list StackList
+	list []*Frame
+	opts Options
 }
 
 // newStackFormatter builds a new stackFormatter.
-func newStackFormatter(layer int) stackFormatter {
+func newStackFormatter(layer int, opts Options) stackFormatter {
+	if depth := opts.depth(); depth > 0 && int(depth) < layer {
+		layer = int(depth)
+	}
 	return stackFormatter{
-		list: make(StackList, 0, layer),
+		list: make([]*Frame, 0, layer),
+		opts: opts,
 	}
 }
 
-func (sf *stackFormatter) Stack() StackList {
+func (sf *stackFormatter) Frames() []*Frame {
 	return sf.list
 }
 
-// FormatStack formats all remaining frames in the provided stacktrace -- minus
-// the final runtime.main/runtime.goexit frame.
-func (sf *stackFormatter) FormatStack(stack *stacktrace) {
-	// nb. On the last iteration, frames.Next() returns false, with a valid
-	// frame, but we ignore this frame. The last frame is a runtime frame which
-	// adds noise, since it's only either runtime.main or runtime.goexit.
-	for frame, more := stack.Next(); more; frame, more = stack.Next() {
-		sf.FormatFrame(frame)
+// FormatStack formats the frames of the program counters that pass the filters,
+// up to the depth of the options -- minus the final runtime.main/runtime.goexit frame.
+func (sf *stackFormatter) FormatStack(pcs []uintptr) {
+	depth := sf.opts.depth()
+	for _, pc := range pcs {
+		for _, frame := range symbolize(pc) {
+			if depth > 0 && len(sf.list) >= int(depth) {
+				return
+			}
+			// nb. The last frame is a runtime frame which adds noise,
+			// since it's only either runtime.main or runtime.goexit.
+			if isEntryFrame(frame) {
+				return
+			}
+			if len(frame.Function) > 0 && sf.opts.keep(frame) {
+				sf.FormatFrame(frame)
+			}
+		}
 	}
 }
 
 // FormatFrame formats the given frame.
 func (sf *stackFormatter) FormatFrame(frame runtime.Frame) {
-	sf.list = append(sf.list, &stackInfo{
+	sf.list = append(sf.list, &Frame{
 		Func: frame.Function,
-		File: frame.File,
+		File: sf.formatPath(frame.File),
 		Line: frame.Line,
 	})
 }
 
+func (sf *stackFormatter) formatPath(file string) string {
+	switch sf.opts.PathMode {
+	case PathTrimmed:
+		return sf.TrimmedPath(file)
+	case PathRelative:
+		return sf.RelativePath(file)
+	default:
+		return file
+	}
+}
+
 // TrimmedPath returns a package/file:line description of the caller,
 // preserving only the leaf directory name and file name.
 func (sf *stackFormatter) TrimmedPath(file string) string {
@@ -198,32 +236,175 @@ func (sf *stackFormatter) TrimmedPath(file string) string {
 	return caller
 }
 
-// stackInfo stack info
-type stackInfo struct {
+// RelativePath returns the path relative to the root it is built from:
+// Options.PathPrefix for the project, GOROOT/src for the standard library,
+// and the module cache for the dependencies, such as 'go.uber.org/zap@v1.24.0/logger.go'.
+// the path is kept as is if none of them matches.
+func (sf *stackFormatter) RelativePath(file string) string {
+	if prefix := sf.opts.PathPrefix; len(prefix) > 0 && strings.HasPrefix(file, prefix) {
+		return strings.TrimLeft(file[len(prefix):], "/")
+	}
+	if idx := strings.LastIndex(file, modCacheDir); idx >= 0 {
+		return file[idx+len(modCacheDir):]
+	}
+	if root := goRootSrc(); len(root) > 0 && strings.HasPrefix(file, root) {
+		return file[len(root):]
+	}
+	return file
+}
+
+// Frame a symbolized stack frame
+type Frame struct {
 	Func string `json:"func"` // function name
 	File string `json:"file"` // file name
 	Line int    `json:"line"` // line no
 }
 
-func (s *stackInfo) MarshalLogObject(enc zapcore.ObjectEncoder) error {
-	enc.AddString("func", s.Func)
-	enc.AddString("file", s.File+":"+strconv.Itoa(s.Line))
+func (f *Frame) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("func", f.Func)
+	enc.AddString("file", f.File+":"+strconv.Itoa(f.Line))
 	return nil
 }
 
-// StackList stack info list
-type StackList []*stackInfo
+// StackList a captured stack.
+// it keeps the raw program counters and symbolizes them lazily when it is printed,
+// since most of the stacks are never printed.
+type StackList struct {
+	pcs    []uintptr
+	opts   Options
+	frames []*Frame // symbolized in advance, such as the ones parsed from a goroutine dump
+}
+
+// NewStackList build a stack of the frames that are already symbolized
+func NewStackList(frames []*Frame) StackList {
+	if frames == nil {
+		frames = make([]*Frame, 0)
+	}
+	return StackList{frames: frames}
+}
+
+// PCs return the raw program counters, nil if the stack is built from frames
+func (sl StackList) PCs() []uintptr {
+	return sl.pcs
+}
+
+// IsEmpty report whether nothing is captured
+func (sl StackList) IsEmpty() bool {
+	return len(sl.pcs) == 0 && len(sl.frames) == 0
+}
+
+// Frames symbolize the stack with the options it is captured with
+func (sl StackList) Frames() []*Frame {
+	if sl.frames != nil || len(sl.pcs) == 0 {
+		return sl.frames
+	}
+	sf := newStackFormatter(len(sl.pcs), sl.opts)
+	sf.FormatStack(sl.pcs)
+	return sf.Frames()
+}
+
+// Fingerprint hash the normalized stack, which is stable across builds and deployments:
+// only the function names are kept, the closure suffixes such as '.func1' are stripped.
+// returns an empty string for an empty stack.
+func (sl StackList) Fingerprint() string {
+	frames := sl.Frames()
+	if len(frames) == 0 {
+		return ""
+	}
+	h := fnv.New64a()
+	for _, f := range frames {
+		_, _ = h.Write([]byte(normalizeFunc(f.Func)))
+		_, _ = h.Write([]byte{'\n'})
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// normalizeFunc strip the closure suffixes, such as 'pkg.(*T).Run.func1.2' -> 'pkg.(*T).Run'
+func normalizeFunc(name string) string {
+	for {
+		idx := strings.LastIndexByte(name, '.')
+		if idx < 0 {
+			return name
+		}
+		last := name[idx+1:]
+		if !isDigits(last) && !(strings.HasPrefix(last, "func") && isDigits(last[len("func"):])) {
+			return name
+		}
+		name = name[:idx]
+	}
+}
+
+func isDigits(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
 
 func (sl StackList) MarshalLogArray(enc zapcore.ArrayEncoder) (err error) {
-	for _, s := range sl {
-		if err = enc.AppendObject(s); err != nil {
+	for _, f := range sl.Frames() {
+		if err = enc.AppendObject(f); err != nil {
 			return err
 		}
 	}
 	return
 }
 
+func (sl StackList) MarshalJSON() ([]byte, error) {
+	frames := sl.Frames()
+	if frames == nil {
+		frames = make([]*Frame, 0)
+	}
+	return json.Marshal(frames)
+}
+
 func (sl StackList) Error() string {
-	out, _ := json.MarshalToString(sl)
-	return out
+	out, _ := sl.MarshalJSON()
+	return string(out)
+}
+
+// =======================================
+// ------------- Frame Cache -------------
+// =======================================
+
+// frameCacheSize the maximum number of program counters cached,
+// the cache is reset when it is full.
+const frameCacheSize = 4096
+
+var (
+	frameCache   = make(map[uintptr][]runtime.Frame, frameCacheSize)
+	frameCacheMu sync.RWMutex
+)
+
+// symbolize resolve the frames of a program counter,
+// there are more than one if the call is inlined.
+func symbolize(pc uintptr) []runtime.Frame {
+	frameCacheMu.RLock()
+	frames, ok := frameCache[pc]
+	frameCacheMu.RUnlock()
+	if ok {
+		return frames
+	}
+
+	iter := runtime.CallersFrames([]uintptr{pc})
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, frame)
+		if !more {
+			break
+		}
+	}
+
+	frameCacheMu.Lock()
+	if len(frameCache) >= frameCacheSize {
+		frameCache = make(map[uintptr][]runtime.Frame, frameCacheSize)
+	}
+	frameCache[pc] = frames
+	frameCacheMu.Unlock()
+	return frames
 }
@@ -1,9 +1,11 @@
 package bstack_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/lamber92/go-brick/bstack"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestTakeStack(t *testing.T) {
@@ -11,3 +13,88 @@ func TestTakeStack(t *testing.T) {
 	t.Logf("%s", stack)
 	// [{"func":"go-brick/bstack_test.TestTakeStack","file":"D:/GitHub/go-brick/bstack/stacktrace_test.go","line":9},{"func":"testing.tRunner","file":"D:/Programs/go1.19.1/go/src/testing/testing.go","line":1446}]
 }
+
+func TestTakeStackWithOptions(t *testing.T) {
+	stack := bstack.TakeStackWithOptions(0, bstack.Options{Depth: 1})
+	assert.Equal(t, 1, len(stack.Frames()))
+	assert.Contains(t, stack.Error(), "bstack_test.TestTakeStackWithOptions")
+
+	// the filtered frames do not count
+	opts := bstack.Options{
+		Depth:    bstack.StacktraceFull,
+		Filters:  []bstack.FrameFilter{bstack.DropRuntime(), bstack.DropTesting()},
+		PathMode: bstack.PathTrimmed,
+	}
+	stack = bstack.TakeStackWithOptions(0, opts)
+	assert.Equal(t, 1, len(stack.Frames()))
+	assert.Equal(t, "bstack/stacktrace_test.go", strings.Split(stack.Error(), `"file":"`)[1][:len("bstack/stacktrace_test.go")])
+
+	stack = bstack.TakeStackWithOptions(0, bstack.Options{
+		Depth:   bstack.StacktraceFull,
+		Filters: []bstack.FrameFilter{bstack.KeepModule("testing")},
+	})
+	assert.Equal(t, 1, len(stack.Frames()))
+	assert.NotContains(t, stack.Error(), "bstack_test")
+
+	// relative to GOROOT
+	stack = bstack.TakeStackWithOptions(0, bstack.Options{Depth: 2, PathMode: bstack.PathRelative})
+	assert.Equal(t, 2, len(stack.Frames()))
+	assert.Contains(t, stack.Error(), `"file":"testing/testing.go"`)
+}
+
+func TestReplaceOptions(t *testing.T) {
+	defer bstack.ReplaceOptions(bstack.GetOptions())
+	bstack.ReplaceOptions(bstack.Options{Filters: []bstack.FrameFilter{bstack.DropTesting()}})
+
+	stack := bstack.TakeStack(0, bstack.StacktraceFull)
+	assert.Equal(t, 1, len(stack.Frames()))
+	assert.Equal(t, 1, len(bstack.TakeStackWithOptions(0, bstack.GetOptions()).Frames()))
+}
+
+func TestStackListLazy(t *testing.T) {
+	stack := bstack.TakeStackWithOptions(0, bstack.Options{Depth: 3})
+	assert.Equal(t, false, stack.IsEmpty())
+	assert.NotEmpty(t, stack.PCs())
+	// symbolized on demand, the same way every time
+	assert.Equal(t, stack.Error(), stack.Error())
+	assert.Equal(t, "bstack_test.TestStackListLazy", stack.Frames()[0].Func[len(stack.Frames()[0].Func)-len("bstack_test.TestStackListLazy"):])
+
+	parsed := bstack.NewStackList([]*bstack.Frame{{Func: "main.main", File: "main.go", Line: 1}})
+	assert.Nil(t, parsed.PCs())
+	assert.Equal(t, `[{"func":"main.main","file":"main.go","line":1}]`, parsed.Error())
+	assert.Equal(t, "[]", bstack.StackList{}.Error())
+}
+
+func BenchmarkTakeStack(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = bstack.TakeStack(0, bstack.StacktraceMax)
+	}
+}
+
+func BenchmarkStackListFrames(b *testing.B) {
+	stack := bstack.TakeStack(0, bstack.StacktraceMax)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = stack.Frames()
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	take := func() bstack.StackList { return bstack.TakeStack(0, bstack.StacktraceMax) }
+	// different lines, same functions
+	s1 := take()
+	s2 := take()
+	assert.NotEqual(t, s1.Frames()[1].Line, s2.Frames()[1].Line)
+	assert.Equal(t, s1.Fingerprint(), s2.Fingerprint())
+	assert.Len(t, s1.Fingerprint(), 16)
+
+	// closures are regarded as the function they belong to
+	a := bstack.NewStackList([]*bstack.Frame{{Func: "main.(*T).Run.func1.2", Line: 1}, {Func: "main.main", Line: 2}})
+	b := bstack.NewStackList([]*bstack.Frame{{Func: "main.(*T).Run", Line: 3}, {Func: "main.main", Line: 4}})
+	c := bstack.NewStackList([]*bstack.Frame{{Func: "main.(*T).Stop", Line: 3}, {Func: "main.main", Line: 4}})
+	assert.Equal(t, a.Fingerprint(), b.Fingerprint())
+	assert.NotEqual(t, a.Fingerprint(), c.Fingerprint())
+	assert.Equal(t, "", bstack.StackList{}.Fingerprint())
+}
@@ -0,0 +1,210 @@
+// Package botel run btrace on an OpenTelemetry TracerProvider.
+//
+// once installed, every btrace span is backed by an OTel span with the same identity,
+// and the metadata appended to the trace chain becomes an event of the current OTel span.
+// the btrace API, the trace chain in logs and the W3C propagation keep working as before.
+package botel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/lamber92/go-brick/btrace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/lamber92/go-brick/btrace"
+
+	attrCode = "b.code"
+)
+
+// Install let btrace create spans through the TracerProvider
+func Install(tp trace.TracerProvider) {
+	btrace.ReplaceSpanProvider(NewSpanProvider(tp))
+}
+
+// Uninstall restore the native btrace spans
+func Uninstall() {
+	btrace.ReplaceSpanProvider(nil)
+}
+
+// NewSpanProvider create a btrace.SpanProvider backed by the TracerProvider
+func NewSpanProvider(tp trace.TracerProvider) btrace.SpanProvider {
+	return &spanProvider{
+		tracer: tp.Tracer(instrumentationName),
+	}
+}
+
+type spanProvider struct {
+	tracer trace.Tracer
+}
+
+func (p *spanProvider) Start(ctx context.Context, parent btrace.SpanContext, name string, attrs []btrace.Attribute) (context.Context, btrace.SpanContext, btrace.SpanPeer) {
+	pctx := parentContext(ctx, parent)
+	octx, span := p.tracer.Start(pctx, name, trace.WithAttributes(toKeyValues(attrs)...))
+
+	sc := FromOTelSpanContext(span.SpanContext())
+	sc.Remote = false
+	if psc := trace.SpanContextFromContext(pctx); psc.IsValid() {
+		sc.ParentSpanID = btrace.SpanID(psc.SpanID())
+	}
+	return octx, sc, &spanPeer{span: span}
+}
+
+// SpanContextFromContext btrace.SpanContextReader impl
+func (p *spanProvider) SpanContextFromContext(ctx context.Context) (btrace.SpanContext, bool) {
+	osc := trace.SpanContextFromContext(ctx)
+	if !osc.IsValid() {
+		return btrace.SpanContext{}, false
+	}
+	return FromOTelSpanContext(osc), true
+}
+
+// parentContext decide the parent of the new OTel span.
+//
+// a plain context.Context carries the OTel span directly, which may have been started by other instrumented libraries,
+// it is preferred over the btrace span context.
+// a bcontext.Context can only carry the btrace span context, the OTel span in its original context is the fallback.
+func parentContext(ctx context.Context, parent btrace.SpanContext) context.Context {
+	if _, ok := ctx.(bcontext.Context); !ok {
+		if trace.SpanContextFromContext(ctx).IsValid() {
+			return ctx
+		}
+	}
+	if !parent.IsValid() {
+		return ctx
+	}
+	if parent.Remote && parent.HasParent() {
+		// the span context prepared by btrace.Extract is never recorded as a span,
+		// the remote span is the real parent.
+		remote := parent
+		remote.SpanID = parent.ParentSpanID
+		return trace.ContextWithRemoteSpanContext(ctx, ToOTelSpanContext(remote))
+	}
+	return trace.ContextWithSpanContext(ctx, ToOTelSpanContext(parent))
+}
+
+// ContextWithSpan put the OTel span of the current btrace span into ctx,
+// so that it can be passed to OTel instrumented libraries.
+// it is necessary for bcontext.Context, which does not carry the OTel span by itself.
+func ContextWithSpan(ctx context.Context) context.Context {
+	if span := SpanFromContext(ctx); span != nil {
+		return trace.ContextWithSpan(ctx, span)
+	}
+	return ctx
+}
+
+// SpanFromContext return the OTel span of the current btrace span,
+// or the OTel span carried by ctx directly, nil if there is none.
+func SpanFromContext(ctx context.Context) trace.Span {
+	if span, ok := btrace.SpanFromCtx(ctx); ok {
+		if peer, ok := span.Peer().(*spanPeer); ok {
+			return peer.span
+		}
+	}
+	if span := trace.SpanFromContext(ctx); span.SpanContext().IsValid() {
+		return span
+	}
+	return nil
+}
+
+// ToOTelSpanContext convert btrace.SpanContext to trace.SpanContext
+func ToOTelSpanContext(sc btrace.SpanContext) trace.SpanContext {
+	ts, _ := trace.ParseTraceState(sc.State.String())
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID(sc.TraceID),
+		SpanID:     trace.SpanID(sc.SpanID),
+		TraceFlags: trace.TraceFlags(sc.Flags),
+		TraceState: ts,
+		Remote:     sc.Remote,
+	})
+}
+
+// FromOTelSpanContext convert trace.SpanContext to btrace.SpanContext.
+// the parent span-id is unknown to trace.SpanContext and left empty.
+func FromOTelSpanContext(sc trace.SpanContext) btrace.SpanContext {
+	return btrace.SpanContext{
+		TraceID: btrace.TraceID(sc.TraceID()),
+		SpanID:  btrace.SpanID(sc.SpanID()),
+		Flags:   btrace.TraceFlags(sc.TraceFlags()),
+		State:   btrace.ParseTraceState(sc.TraceState().String()),
+		Remote:  sc.IsRemote(),
+	}
+}
+
+// spanPeer btrace.SpanPeer impl
+type spanPeer struct {
+	span trace.Span
+}
+
+func (p *spanPeer) SetAttributes(attrs ...btrace.Attribute) {
+	p.span.SetAttributes(toKeyValues(attrs)...)
+}
+
+func (p *spanPeer) AddEvent(name string, t time.Time, attrs ...btrace.Attribute) {
+	p.span.AddEvent(name, trace.WithTimestamp(t), trace.WithAttributes(toKeyValues(attrs)...))
+}
+
+func (p *spanPeer) SetError(err error, status bstatus.Status) {
+	p.span.RecordError(err)
+	p.span.SetStatus(codes.Error, status.Reason())
+	p.span.SetAttributes(attribute.Int(attrCode, status.Code().ToInt()))
+}
+
+func (p *spanPeer) End(t time.Time) {
+	p.span.End(trace.WithTimestamp(t))
+}
+
+func toKeyValues(attrs []btrace.Attribute) []attribute.KeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	out := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		out = append(out, toKeyValue(attr))
+	}
+	return out
+}
+
+func toKeyValue(attr btrace.Attribute) attribute.KeyValue {
+	switch v := attr.Value.(type) {
+	case string:
+		return attribute.String(attr.Key, v)
+	case bool:
+		return attribute.Bool(attr.Key, v)
+	case int:
+		return attribute.Int(attr.Key, v)
+	case int8:
+		return attribute.Int64(attr.Key, int64(v))
+	case int16:
+		return attribute.Int64(attr.Key, int64(v))
+	case int32:
+		return attribute.Int64(attr.Key, int64(v))
+	case int64:
+		return attribute.Int64(attr.Key, v)
+	case uint:
+		return attribute.Int64(attr.Key, int64(v))
+	case uint8:
+		return attribute.Int64(attr.Key, int64(v))
+	case uint16:
+		return attribute.Int64(attr.Key, int64(v))
+	case uint32:
+		return attribute.Int64(attr.Key, int64(v))
+	case float32:
+		return attribute.Float64(attr.Key, float64(v))
+	case float64:
+		return attribute.Float64(attr.Key, v)
+	case []string:
+		return attribute.StringSlice(attr.Key, v)
+	case fmt.Stringer:
+		return attribute.Stringer(attr.Key, v)
+	default:
+		return attribute.String(attr.Key, attr.ValueString())
+	}
+}
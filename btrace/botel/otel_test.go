@@ -0,0 +1,123 @@
+package botel_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/btrace"
+	"github.com/lamber92/go-brick/btrace/botel"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setup(t *testing.T) *tracetest.InMemoryExporter {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	botel.Install(tp)
+	t.Cleanup(func() {
+		botel.Uninstall()
+		_ = tp.Shutdown(context.Background())
+	})
+	return exp
+}
+
+func TestSpanBridge(t *testing.T) {
+	exp := setup(t)
+
+	ctx := bcontext.New()
+	_, parent := btrace.StartSpan(ctx, "parent", btrace.Attr("k1", "v1"))
+	_, child := btrace.StartSpan(ctx, "child", btrace.Attr("k2", 2))
+	btrace.AppendMDIntoCtx(ctx, btrace.NewMD("test_mod", "test metadata"))
+	child.SetError(berror.NewNotFound(nil, "not found"))
+	child.End()
+	parent.End()
+
+	spans := exp.GetSpans()
+	assert.Equal(t, 2, len(spans))
+	c, p := spans[0], spans[1]
+
+	// same identity on both sides
+	assert.Equal(t, "child", c.Name)
+	assert.Equal(t, child.SpanContext().TraceID.String(), c.SpanContext.TraceID().String())
+	assert.Equal(t, child.SpanContext().SpanID.String(), c.SpanContext.SpanID().String())
+	assert.Equal(t, parent.SpanContext().SpanID.String(), c.Parent.SpanID().String())
+	assert.Equal(t, parent.SpanContext().SpanID, child.SpanContext().ParentSpanID)
+	assert.Equal(t, false, p.Parent.IsValid())
+	assert.Equal(t, child.EndTime(), c.EndTime)
+
+	// status and attributes
+	assert.Equal(t, codes.Error, c.Status.Code)
+	assert.Equal(t, codes.Unset, p.Status.Code)
+	attrs := map[string]any{}
+	for _, kv := range c.Attributes {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	assert.Equal(t, int64(2), attrs["k2"])
+	assert.Equal(t, int64(404), attrs["b.code"])
+
+	// the metadata becomes an event, the error is recorded as well
+	names := make([]string, 0, len(c.Events))
+	for _, ev := range c.Events {
+		names = append(names, ev.Name)
+	}
+	assert.Equal(t, []string{"test_mod", "exception"}, names)
+
+	// the chain in logs is kept
+	chain, ok := btrace.GetMDFromCtx(ctx)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 3, len(chain.Get()))
+}
+
+func TestSpanBridgeInterop(t *testing.T) {
+	exp := setup(t)
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)).Tracer("other")
+
+	// a span started by another instrumented library is the parent
+	octx, ospan := tracer.Start(context.Background(), "upstream")
+	ctx, span := btrace.StartSpan(octx, "btrace")
+	assert.Equal(t, ospan.SpanContext().SpanID().String(), span.SpanContext().ParentSpanID.String())
+
+	// and the btrace span is visible to the library
+	assert.Equal(t, span.SpanContext().SpanID.String(), trace.SpanFromContext(ctx).SpanContext().SpanID().String())
+	dctx, inner := tracer.Start(ctx, "downstream")
+	// the span of the library is read through btrace, as the logger does
+	psc, ok := btrace.GetPeerSpanContext(dctx)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, inner.SpanContext().SpanID().String(), psc.SpanID.String())
+	assert.Equal(t, span.SpanContext().TraceID, psc.TraceID)
+	inner.End()
+	span.End()
+	ospan.End()
+
+	spans := exp.GetSpans()
+	assert.Equal(t, 3, len(spans))
+	assert.Equal(t, span.SpanContext().SpanID.String(), spans[0].Parent.SpanID().String())
+
+	// bcontext needs ContextWithSpan to hand the span over
+	bctx := bcontext.New()
+	_, span2 := btrace.StartSpan(bctx, "bcontext")
+	assert.Equal(t, false, trace.SpanFromContext(bctx).SpanContext().IsValid())
+	assert.Equal(t, span2.SpanContext().SpanID.String(),
+		trace.SpanFromContext(botel.ContextWithSpan(bctx)).SpanContext().SpanID().String())
+	span2.End()
+}
+
+func TestSpanBridgeRemoteParent(t *testing.T) {
+	exp := setup(t)
+
+	carrier := btrace.MapCarrier{btrace.HeaderTraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	ctx := btrace.Extract(bcontext.New(), carrier)
+	_, span := btrace.StartSpan(ctx, "server")
+	span.End()
+
+	spans := exp.GetSpans()
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent.SpanID().String())
+	assert.Equal(t, true, spans[0].Parent.IsRemote())
+}
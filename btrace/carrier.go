@@ -0,0 +1,38 @@
+package btrace
+
+import (
+	"context"
+	"net/http"
+)
+
+// HTTPHeaderCarrier adapt http.Header to Carrier
+type HTTPHeaderCarrier http.Header
+
+func (c HTTPHeaderCarrier) Get(key string) string {
+	return http.Header(c).Get(key)
+}
+
+func (c HTTPHeaderCarrier) Set(key string, value string) {
+	http.Header(c).Set(key, value)
+}
+
+// MapCarrier adapt map[string]string to Carrier
+type MapCarrier map[string]string
+
+func (c MapCarrier) Get(key string) string {
+	return c[key]
+}
+
+func (c MapCarrier) Set(key string, value string) {
+	c[key] = value
+}
+
+// InjectHTTPHeader write the trace context of ctx into the http header
+func InjectHTTPHeader(ctx context.Context, header http.Header) {
+	Inject(ctx, HTTPHeaderCarrier(header))
+}
+
+// ExtractHTTPHeader read the trace context from the http header into ctx
+func ExtractHTTPHeader(ctx context.Context, header http.Header) context.Context {
+	return Extract(ctx, HTTPHeaderCarrier(header))
+}
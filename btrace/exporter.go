@@ -0,0 +1,252 @@
+package btrace
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lamber92/go-brick/berror"
+)
+
+const (
+	defaultExportQueueSize = 2048
+	defaultExportBatchSize = 512
+	defaultExportInterval  = time.Second * 5
+	defaultExportTimeout   = time.Second * 30
+)
+
+// Exporter send finished spans to a tracing backend
+type Exporter interface {
+	// Export send a batch of finished spans.
+	// it is never called concurrently by the same BatchProcessor.
+	Export(ctx context.Context, spans []Span) error
+	// Shutdown release the resources held by the exporter
+	Shutdown(ctx context.Context) error
+}
+
+// BatchConfig the batching options of BatchProcessor.
+// zero values fall back to the defaults.
+type BatchConfig struct {
+	// QueueSize the maximum number of spans waiting for export, spans beyond it are dropped.
+	QueueSize int
+	// BatchSize the maximum number of spans exported at a time
+	BatchSize int
+	// Interval the maximum delay before the queued spans are exported
+	Interval time.Duration
+	// Timeout the maximum duration of a single export
+	Timeout time.Duration
+	// OnError called when the exporter returns an error
+	OnError func(err error)
+}
+
+func (c *BatchConfig) fill() {
+	if c.QueueSize <= 0 {
+		c.QueueSize = defaultExportQueueSize
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultExportBatchSize
+	}
+	if c.BatchSize > c.QueueSize {
+		c.BatchSize = c.QueueSize
+	}
+	if c.Interval <= 0 {
+		c.Interval = defaultExportInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultExportTimeout
+	}
+}
+
+// BatchProcessor buffer finished spans in a bounded queue and hand them to the exporter in batches.
+// it never blocks the caller, spans are dropped when the queue is full.
+type BatchProcessor struct {
+	exporter Exporter
+	conf     BatchConfig
+
+	queue   chan Span
+	flush   chan chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	closed  atomic.Bool
+	dropped atomic.Uint64
+	// mu keep Shutdown from closing bp between the closed check and the push of Enqueue,
+	// so that a span is either drained by Shutdown or counted as dropped.
+	mu sync.RWMutex
+}
+
+// NewBatchProcessor create a BatchProcessor and start its worker
+func NewBatchProcessor(exporter Exporter, conf ...BatchConfig) *BatchProcessor {
+	var c BatchConfig
+	if len(conf) > 0 {
+		c = conf[0]
+	}
+	c.fill()
+	bp := &BatchProcessor{
+		exporter: exporter,
+		conf:     c,
+		queue:    make(chan Span, c.QueueSize),
+		flush:    make(chan chan struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go bp.work()
+	return bp
+}
+
+// Enqueue put the finished span into the queue.
+// returns 'false' if the span is dropped.
+func (bp *BatchProcessor) Enqueue(span Span) bool {
+	bp.mu.RLock()
+	defer bp.mu.RUnlock()
+	if bp.closed.Load() {
+		bp.dropped.Add(1)
+		return false
+	}
+	select {
+	case bp.queue <- span:
+		return true
+	default:
+		bp.dropped.Add(1)
+		return false
+	}
+}
+
+// Dropped return the number of spans dropped so far
+func (bp *BatchProcessor) Dropped() uint64 {
+	return bp.dropped.Load()
+}
+
+// Flush export all the queued spans immediately and wait for it
+func (bp *BatchProcessor) Flush(ctx context.Context) error {
+	if bp.closed.Load() {
+		return nil
+	}
+	ack := make(chan struct{})
+	select {
+	case bp.flush <- ack:
+	case <-bp.done:
+		return nil
+	case <-ctx.Done():
+		return berror.NewRequestTimeout(ctx.Err(), "flush trace exporter timeout")
+	}
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return berror.NewRequestTimeout(ctx.Err(), "flush trace exporter timeout")
+	}
+}
+
+// Shutdown export the remaining spans, stop the worker and shut down the exporter.
+// only the first call takes effect.
+func (bp *BatchProcessor) Shutdown(ctx context.Context) (err error) {
+	bp.once.Do(func() {
+		bp.mu.Lock()
+		bp.closed.Store(true)
+		bp.mu.Unlock()
+		close(bp.stop)
+		select {
+		case <-bp.done:
+		case <-ctx.Done():
+			err = berror.NewRequestTimeout(ctx.Err(), "shutdown trace exporter timeout")
+			return
+		}
+		err = bp.exporter.Shutdown(ctx)
+	})
+	return
+}
+
+func (bp *BatchProcessor) work() {
+	defer close(bp.done)
+	ticker := time.NewTicker(bp.conf.Interval)
+	defer ticker.Stop()
+
+	batch := make([]Span, 0, bp.conf.BatchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), bp.conf.Timeout)
+		if err := bp.exporter.Export(ctx, batch); err != nil && bp.conf.OnError != nil {
+			bp.conf.OnError(err)
+		}
+		cancel()
+		// the exporter may hold the slice, never reuse it
+		batch = make([]Span, 0, bp.conf.BatchSize)
+	}
+	drain := func() {
+		for {
+			select {
+			case span := <-bp.queue:
+				batch = append(batch, span)
+				if len(batch) >= bp.conf.BatchSize {
+					export()
+				}
+			default:
+				export()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case span := <-bp.queue:
+			batch = append(batch, span)
+			if len(batch) >= bp.conf.BatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case ack := <-bp.flush:
+			drain()
+			close(ack)
+		case <-bp.stop:
+			drain()
+			return
+		}
+	}
+}
+
+var _processor atomic.Pointer[BatchProcessor]
+
+// ReplaceExporter export every finished span through the exporter.
+// the previous exporter is shut down after its remaining spans are exported.
+// a nil exporter turns off exporting.
+func ReplaceExporter(exporter Exporter, conf ...BatchConfig) *BatchProcessor {
+	var bp *BatchProcessor
+	if exporter != nil {
+		bp = NewBatchProcessor(exporter, conf...)
+	}
+	if old := _processor.Swap(bp); old != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), old.conf.Timeout)
+		_ = old.Shutdown(ctx)
+		cancel()
+	}
+	return bp
+}
+
+// FlushExporter export all the queued spans immediately
+func FlushExporter(ctx context.Context) error {
+	if bp := _processor.Load(); bp != nil {
+		return bp.Flush(ctx)
+	}
+	return nil
+}
+
+// ShutdownExporter export the remaining spans and turn off exporting
+func ShutdownExporter(ctx context.Context) error {
+	if bp := _processor.Swap(nil); bp != nil {
+		return bp.Shutdown(ctx)
+	}
+	return nil
+}
+
+// exportSpan hand the finished span to the current exporter
+func exportSpan(span Span) {
+	if bp := _processor.Load(); bp != nil {
+		bp.Enqueue(span)
+	}
+}
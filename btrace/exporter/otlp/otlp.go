@@ -0,0 +1,262 @@
+// Package otlp export btrace spans to an OpenTelemetry collector with OTLP/JSON over HTTP.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/btrace"
+	"github.com/lamber92/go-brick/internal/json"
+)
+
+const (
+	// DefaultEndpoint the traces api of a local OpenTelemetry collector
+	DefaultEndpoint = "http://localhost:4318/v1/traces"
+
+	scopeName = "github.com/lamber92/go-brick/btrace"
+
+	attrServiceName = "service.name"
+	attrCode        = "b.code"
+)
+
+// status codes and span kinds defined by opentelemetry-proto
+const (
+	statusCodeUnset = 0
+	statusCodeError = 2
+
+	spanKindInternal = 1
+)
+
+// Config OTLP exporter options
+type Config struct {
+	// Endpoint the url of the traces api, DefaultEndpoint if empty
+	Endpoint string
+	// ServiceName the 'service.name' resource attribute
+	ServiceName string
+	// ResourceAttributes extra resource attributes, such as 'deployment.environment'
+	ResourceAttributes []btrace.Attribute
+	// Headers extra http headers, such as authorization
+	Headers map[string]string
+	// Client the http client, http.DefaultClient if nil
+	Client *http.Client
+}
+
+// Exporter btrace.Exporter impl
+type Exporter struct {
+	conf     Config
+	resource resource
+}
+
+// New create an OTLP/JSON exporter
+func New(conf Config) *Exporter {
+	if len(conf.Endpoint) == 0 {
+		conf.Endpoint = DefaultEndpoint
+	}
+	if conf.Client == nil {
+		conf.Client = http.DefaultClient
+	}
+	res := resource{Attributes: make([]keyValue, 0, len(conf.ResourceAttributes)+1)}
+	if len(conf.ServiceName) > 0 {
+		res.Attributes = append(res.Attributes, toKeyValue(btrace.Attr(attrServiceName, conf.ServiceName)))
+	}
+	for _, attr := range conf.ResourceAttributes {
+		res.Attributes = append(res.Attributes, toKeyValue(attr))
+	}
+	return &Exporter{conf: conf, resource: res}
+}
+
+// Export post the spans to the collector
+func (e *Exporter) Export(ctx context.Context, spans []btrace.Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	models := make([]*spanModel, 0, len(spans))
+	for _, s := range spans {
+		models = append(models, toModel(s))
+	}
+	body, err := json.Marshal(&exportRequest{
+		ResourceSpans: []resourceSpans{{
+			Resource: e.resource,
+			ScopeSpans: []scopeSpans{{
+				Scope: scope{Name: scopeName},
+				Spans: models,
+			}},
+		}},
+	})
+	if err != nil {
+		return berror.NewInternalError(err, "marshal otlp spans failed")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.conf.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return berror.NewInvalidArgument(err, "build otlp request failed")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.conf.Client.Do(req)
+	if err != nil {
+		return berror.Convert(err, "post otlp spans failed")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return berror.NewInternalError(nil, fmt.Sprintf("otlp collector responded with status %d", resp.StatusCode))
+	}
+	return nil
+}
+
+// Shutdown nothing to release
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// the following models are the JSON mapping of opentelemetry-proto ExportTraceServiceRequest.
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+// nb. trace-id and span-id are hex strings, 64-bit integers are decimal strings.
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope        `json:"scope"`
+	Spans []*spanModel `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type spanModel struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	TraceState        string     `json:"traceState,omitempty"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Events            []event    `json:"events,omitempty"`
+	Status            status     `json:"status"`
+}
+
+type event struct {
+	TimeUnixNano string     `json:"timeUnixNano"`
+	Name         string     `json:"name"`
+	Attributes   []keyValue `json:"attributes,omitempty"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func toModel(s btrace.Span) *spanModel {
+	sc := s.SpanContext()
+	end := s.EndTime()
+	if end.IsZero() {
+		end = s.StartTime().Add(s.Duration())
+	}
+	out := &spanModel{
+		TraceID:           sc.TraceID.String(),
+		SpanID:            sc.SpanID.String(),
+		TraceState:        sc.State.String(),
+		Name:              s.Name(),
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+		Status:            status{Code: statusCodeUnset},
+	}
+	if sc.HasParent() {
+		out.ParentSpanID = sc.ParentSpanID.String()
+	}
+	for _, attr := range btrace.RedactAttributes(s.Module(), s.Attributes()) {
+		out.Attributes = append(out.Attributes, toKeyValue(attr))
+	}
+	if err := s.Err(); err != nil {
+		out.Status = status{Code: statusCodeError, Message: berror.RedactError(err)}
+		out.Attributes = append(out.Attributes, toKeyValue(btrace.Attr(attrCode, s.Status().Code().ToInt())))
+	}
+	for _, ev := range s.Events() {
+		tmp := event{
+			TimeUnixNano: strconv.FormatInt(ev.Time.UnixNano(), 10),
+			Name:         ev.Name,
+		}
+		for _, attr := range btrace.RedactAttributes(s.Module(), ev.Attributes) {
+			tmp.Attributes = append(tmp.Attributes, toKeyValue(attr))
+		}
+		out.Events = append(out.Events, tmp)
+	}
+	return out
+}
+
+func toKeyValue(attr btrace.Attribute) keyValue {
+	out := keyValue{Key: attr.Key}
+	switch v := attr.Value.(type) {
+	case bool:
+		out.Value.BoolValue = &v
+	case int:
+		out.Value.IntValue = formatInt(int64(v))
+	case int8:
+		out.Value.IntValue = formatInt(int64(v))
+	case int16:
+		out.Value.IntValue = formatInt(int64(v))
+	case int32:
+		out.Value.IntValue = formatInt(int64(v))
+	case int64:
+		out.Value.IntValue = formatInt(v)
+	case uint:
+		out.Value.IntValue = formatInt(int64(v))
+	case uint8:
+		out.Value.IntValue = formatInt(int64(v))
+	case uint16:
+		out.Value.IntValue = formatInt(int64(v))
+	case uint32:
+		out.Value.IntValue = formatInt(int64(v))
+	case float32:
+		tmp := float64(v)
+		out.Value.DoubleValue = &tmp
+	case float64:
+		out.Value.DoubleValue = &v
+	default:
+		tmp := attr.ValueString()
+		out.Value.StringValue = &tmp
+	}
+	return out
+}
+
+func formatInt(v int64) *string {
+	out := strconv.FormatInt(v, 10)
+	return &out
+}
+
+var _ btrace.Exporter = (*Exporter)(nil)
@@ -0,0 +1,107 @@
+package otlp_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/btrace"
+	"github.com/lamber92/go-brick/btrace/exporter/otlp"
+	"github.com/lamber92/go-brick/internal/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	var (
+		body   []byte
+		path   string
+		header http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ctx := bcontext.New()
+	_, parent := btrace.StartSpan(ctx, "parent", btrace.Attr("str", "v"), btrace.Attr("int", 2), btrace.Attr("bool", true))
+	_, child := btrace.StartSpan(ctx, "child")
+	child.AddEvent("retry", btrace.Attr("times", 1))
+	child.SetError(berror.NewNotFound(nil, "not found"))
+	child.End()
+	parent.End()
+
+	exp := otlp.New(otlp.Config{
+		Endpoint:           srv.URL + "/v1/traces",
+		ServiceName:        "test-service",
+		ResourceAttributes: []btrace.Attribute{btrace.Attr("deployment.environment", "dev")},
+	})
+	err := exp.Export(context.Background(), []btrace.Span{child, parent})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "/v1/traces", path)
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+
+	// resource
+	assert.Equal(t, "service.name", json.Get(body, "resourceSpans", 0, "resource", "attributes", 0, "key").ToString())
+	assert.Equal(t, "test-service", json.Get(body, "resourceSpans", 0, "resource", "attributes", 0, "value", "stringValue").ToString())
+	assert.Equal(t, "dev", json.Get(body, "resourceSpans", 0, "resource", "attributes", 1, "value", "stringValue").ToString())
+
+	spans := json.Get(body, "resourceSpans", 0, "scopeSpans", 0, "spans")
+	assert.Equal(t, 2, spans.Size())
+
+	c := spans.Get(0)
+	assert.Equal(t, "child", c.Get("name").ToString())
+	assert.Equal(t, child.SpanContext().TraceID.String(), c.Get("traceId").ToString())
+	assert.Equal(t, child.SpanContext().SpanID.String(), c.Get("spanId").ToString())
+	assert.Equal(t, parent.SpanContext().SpanID.String(), c.Get("parentSpanId").ToString())
+	assert.Equal(t, strconv.FormatInt(child.StartTime().UnixNano(), 10), c.Get("startTimeUnixNano").ToString())
+	assert.Equal(t, strconv.FormatInt(child.EndTime().UnixNano(), 10), c.Get("endTimeUnixNano").ToString())
+	assert.Equal(t, 2, c.Get("status", "code").ToInt())
+	assert.Contains(t, c.Get("status", "message").ToString(), "not found")
+	assert.Equal(t, "b.code", c.Get("attributes", 0, "key").ToString())
+	assert.Equal(t, "404", c.Get("attributes", 0, "value", "intValue").ToString())
+	assert.Equal(t, "retry", c.Get("events", 0, "name").ToString())
+	assert.Equal(t, "1", c.Get("events", 0, "attributes", 0, "value", "intValue").ToString())
+
+	p := spans.Get(1)
+	assert.Equal(t, "", p.Get("parentSpanId").ToString())
+	assert.Equal(t, 0, p.Get("status", "code").ToInt())
+	assert.Equal(t, "v", p.Get("attributes", 0, "value", "stringValue").ToString())
+	assert.Equal(t, "2", p.Get("attributes", 1, "value", "intValue").ToString())
+	assert.Equal(t, true, p.Get("attributes", 2, "value", "boolValue").ToBool())
+}
+
+func TestExportWithBatchProcessor(t *testing.T) {
+	received := make(chan int, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- json.Get(body, "resourceSpans", 0, "scopeSpans", 0, "spans").Size()
+	}))
+	defer srv.Close()
+
+	btrace.ReplaceExporter(otlp.New(otlp.Config{Endpoint: srv.URL}), btrace.BatchConfig{Interval: time.Millisecond * 10})
+	defer btrace.ReplaceExporter(nil)
+
+	for i := 0; i < 3; i++ {
+		_, span := btrace.StartSpan(context.Background(), "test")
+		span.End()
+	}
+	total := 0
+	for total < 3 {
+		select {
+		case n := <-received:
+			total += n
+		case <-time.After(time.Second * 3):
+			t.Fatal("export timeout")
+		}
+	}
+	assert.Equal(t, 3, total)
+}
@@ -0,0 +1,169 @@
+// Package zipkin export btrace spans to a Zipkin collector in the v2 JSON format.
+package zipkin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/btrace"
+	"github.com/lamber92/go-brick/internal/json"
+)
+
+const (
+	// DefaultEndpoint the span collecting api of a local Zipkin server
+	DefaultEndpoint = "http://localhost:9411/api/v2/spans"
+
+	tagError = "error"
+	tagCode  = "b.code"
+)
+
+// Config Zipkin exporter options
+type Config struct {
+	// Endpoint the url of the span collecting api, DefaultEndpoint if empty
+	Endpoint string
+	// ServiceName the local service name reported with every span
+	ServiceName string
+	// Headers extra http headers, such as authorization
+	Headers map[string]string
+	// Client the http client, http.DefaultClient if nil
+	Client *http.Client
+}
+
+// Exporter btrace.Exporter impl
+type Exporter struct {
+	conf Config
+}
+
+// New create a Zipkin exporter
+func New(conf Config) *Exporter {
+	if len(conf.Endpoint) == 0 {
+		conf.Endpoint = DefaultEndpoint
+	}
+	if conf.Client == nil {
+		conf.Client = http.DefaultClient
+	}
+	return &Exporter{conf: conf}
+}
+
+// Export post the spans to the collector
+func (e *Exporter) Export(ctx context.Context, spans []btrace.Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	models := make([]*spanModel, 0, len(spans))
+	for _, s := range spans {
+		models = append(models, e.toModel(s))
+	}
+	body, err := json.Marshal(models)
+	if err != nil {
+		return berror.NewInternalError(err, "marshal zipkin spans failed")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.conf.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return berror.NewInvalidArgument(err, "build zipkin request failed")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.conf.Client.Do(req)
+	if err != nil {
+		return berror.Convert(err, "post zipkin spans failed")
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return berror.NewInternalError(nil, fmt.Sprintf("zipkin collector responded with status %d", resp.StatusCode))
+	}
+	return nil
+}
+
+// Shutdown nothing to release
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+type endpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+type annotation struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+// spanModel zipkin v2 span
+// https://zipkin.io/zipkin-api/#/default/post_spans
+type spanModel struct {
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId,omitempty"`
+	Name          string            `json:"name"`
+	Timestamp     int64             `json:"timestamp"`
+	Duration      int64             `json:"duration"`
+	LocalEndpoint *endpoint         `json:"localEndpoint,omitempty"`
+	Annotations   []annotation      `json:"annotations,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+func (e *Exporter) toModel(s btrace.Span) *spanModel {
+	sc := s.SpanContext()
+	out := &spanModel{
+		TraceID:   sc.TraceID.String(),
+		ID:        sc.SpanID.String(),
+		Name:      s.Name(),
+		Timestamp: s.StartTime().UnixMicro(),
+		Duration:  s.Duration().Microseconds(),
+	}
+	if sc.HasParent() {
+		out.ParentID = sc.ParentSpanID.String()
+	}
+	// zipkin treats 0 as an unknown duration
+	if out.Duration <= 0 {
+		out.Duration = 1
+	}
+	if len(e.conf.ServiceName) > 0 {
+		out.LocalEndpoint = &endpoint{ServiceName: e.conf.ServiceName}
+	}
+
+	attrs := btrace.RedactAttributes(s.Module(), s.Attributes())
+	tags := make(map[string]string, len(attrs)+2)
+	for _, attr := range attrs {
+		tags[attr.Key] = attr.ValueString()
+	}
+	if err := s.Err(); err != nil {
+		tags[tagError] = berror.RedactError(err)
+		tags[tagCode] = s.Status().Code().ToString()
+	}
+	if len(tags) > 0 {
+		out.Tags = tags
+	}
+
+	for _, ev := range s.Events() {
+		out.Annotations = append(out.Annotations, annotation{
+			Timestamp: ev.Time.UnixMicro(),
+			Value:     eventValue(s.Module(), ev),
+		})
+	}
+	return out
+}
+
+// eventValue zipkin annotations only carry a string, the attributes are flattened into it
+func eventValue(module btrace.Module, ev btrace.Event) string {
+	if len(ev.Attributes) == 0 {
+		return ev.Name
+	}
+	attrs := make(map[string]string, len(ev.Attributes))
+	for _, attr := range btrace.RedactAttributes(module, ev.Attributes) {
+		attrs[attr.Key] = attr.ValueString()
+	}
+	tmp, _ := json.MarshalToString(attrs)
+	return ev.Name + " " + tmp
+}
+
+var _ btrace.Exporter = (*Exporter)(nil)
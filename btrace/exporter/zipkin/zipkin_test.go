@@ -0,0 +1,120 @@
+package zipkin_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/btrace"
+	"github.com/lamber92/go-brick/btrace/exporter/zipkin"
+	"github.com/lamber92/go-brick/internal/json"
+	"github.com/stretchr/testify/assert"
+)
+
+type zipkinSpan struct {
+	TraceID       string `json:"traceId"`
+	ID            string `json:"id"`
+	ParentID      string `json:"parentId"`
+	Name          string `json:"name"`
+	Timestamp     int64  `json:"timestamp"`
+	Duration      int64  `json:"duration"`
+	LocalEndpoint struct {
+		ServiceName string `json:"serviceName"`
+	} `json:"localEndpoint"`
+	Annotations []struct {
+		Timestamp int64  `json:"timestamp"`
+		Value     string `json:"value"`
+	} `json:"annotations"`
+	Tags map[string]string `json:"tags"`
+}
+
+func TestExport(t *testing.T) {
+	var (
+		received []zipkinSpan
+		header   http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	ctx := bcontext.New()
+	_, parent := btrace.StartSpan(ctx, "parent", btrace.Attr("k1", "v1"), btrace.Attr("k2", 2))
+	_, child := btrace.StartSpan(ctx, "child")
+	child.AddEvent("retry", btrace.Attr("times", 1))
+	child.SetError(berror.NewNotFound(nil, "not found"))
+	child.End()
+	parent.End()
+
+	exp := zipkin.New(zipkin.Config{
+		Endpoint:    srv.URL,
+		ServiceName: "test-service",
+		Headers:     map[string]string{"Authorization": "Bearer test"},
+	})
+	err := exp.Export(context.Background(), []btrace.Span{child, parent})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "Bearer test", header.Get("Authorization"))
+
+	assert.Equal(t, 2, len(received))
+	c, p := received[0], received[1]
+	assert.Equal(t, parent.SpanContext().TraceID.String(), c.TraceID)
+	assert.Equal(t, parent.SpanContext().SpanID.String(), c.ParentID)
+	assert.Equal(t, child.SpanContext().SpanID.String(), c.ID)
+	assert.Equal(t, "child", c.Name)
+	assert.Equal(t, "test-service", c.LocalEndpoint.ServiceName)
+	assert.Equal(t, child.StartTime().UnixMicro(), c.Timestamp)
+	assert.Less(t, int64(0), c.Duration)
+	assert.Equal(t, "404", c.Tags["b.code"])
+	assert.Contains(t, c.Tags["error"], "not found")
+	assert.Equal(t, 1, len(c.Annotations))
+	assert.Equal(t, `retry {"times":"1"}`, c.Annotations[0].Value)
+
+	assert.Equal(t, "", p.ParentID)
+	assert.Equal(t, map[string]string{"k1": "v1", "k2": "2"}, p.Tags)
+}
+
+func TestExportFailed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	_, span := btrace.StartSpan(context.Background(), "test")
+	span.End()
+	err := zipkin.New(zipkin.Config{Endpoint: srv.URL}).Export(context.Background(), []btrace.Span{span})
+	assert.NotEqual(t, nil, err)
+	assert.Contains(t, err.Error(), "400")
+}
+
+func TestExportRedacted(t *testing.T) {
+	var received []zipkinSpan
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &received)
+	}))
+	defer srv.Close()
+
+	const mod = "zipkin_redact_test"
+	keys, err := btrace.RedactKeys(`(?i)^token$`)
+	assert.Equal(t, nil, err)
+	btrace.RegisterRedactor(mod, keys)
+
+	_, span := btrace.StartSpan(bcontext.New(), mod, btrace.Attr("token", "secret-token"), btrace.Attr("user", "lamber"))
+	span.AddEvent("login", btrace.Attr("token", "secret-token"))
+	span.End()
+	err = zipkin.New(zipkin.Config{Endpoint: srv.URL}).Export(context.Background(), []btrace.Span{span})
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, 1, len(received))
+	assert.Equal(t, map[string]string{"token": "******", "user": "lamber"}, received[0].Tags)
+	assert.Equal(t, `login {"token":"******"}`, received[0].Annotations[0].Value)
+	assert.NotContains(t, span.String(), "secret-token")
+}
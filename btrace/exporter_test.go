@@ -0,0 +1,129 @@
+package btrace_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/btrace"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryExporter struct {
+	mu       sync.Mutex
+	batches  [][]btrace.Span
+	block    chan struct{}
+	shutdown bool
+}
+
+func (m *memoryExporter) Export(ctx context.Context, spans []btrace.Span) error {
+	if m.block != nil {
+		<-m.block
+	}
+	m.mu.Lock()
+	m.batches = append(m.batches, spans)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memoryExporter) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.shutdown = true
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memoryExporter) count() (batches, spans int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, b := range m.batches {
+		spans += len(b)
+	}
+	return len(m.batches), spans
+}
+
+func TestBatchProcessor(t *testing.T) {
+	exp := &memoryExporter{}
+	bp := btrace.NewBatchProcessor(exp, btrace.BatchConfig{BatchSize: 2, Interval: time.Hour})
+	for i := 0; i < 5; i++ {
+		_, span := btrace.StartSpan(context.Background(), "test")
+		span.End()
+		assert.Equal(t, true, bp.Enqueue(span))
+	}
+	assert.Equal(t, nil, bp.Flush(context.Background()))
+	batches, spans := exp.count()
+	assert.Equal(t, 3, batches)
+	assert.Equal(t, 5, spans)
+
+	assert.Equal(t, nil, bp.Shutdown(context.Background()))
+	assert.Equal(t, true, exp.shutdown)
+
+	// closed processor drops everything
+	_, span := btrace.StartSpan(context.Background(), "test")
+	assert.Equal(t, false, bp.Enqueue(span))
+	assert.Equal(t, uint64(1), bp.Dropped())
+}
+
+func TestBatchProcessorDrop(t *testing.T) {
+	exp := &memoryExporter{block: make(chan struct{})}
+	bp := btrace.NewBatchProcessor(exp, btrace.BatchConfig{QueueSize: 2, BatchSize: 1, Interval: time.Hour})
+	_, span := btrace.StartSpan(context.Background(), "test")
+	// the worker takes the first span and blocks in Export, the queue holds the next two
+	assert.Equal(t, true, bp.Enqueue(span))
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, true, bp.Enqueue(span))
+	assert.Equal(t, true, bp.Enqueue(span))
+	assert.Equal(t, false, bp.Enqueue(span))
+	assert.Equal(t, uint64(1), bp.Dropped())
+
+	close(exp.block)
+	assert.Equal(t, nil, bp.Shutdown(context.Background()))
+	_, spans := exp.count()
+	assert.Equal(t, 3, spans)
+}
+
+func TestBatchProcessorShutdownRace(t *testing.T) {
+	exp := &memoryExporter{}
+	bp := btrace.NewBatchProcessor(exp, btrace.BatchConfig{QueueSize: 4096, Interval: time.Hour})
+	_, span := btrace.StartSpan(context.Background(), "test")
+
+	const workers, perWorker = 8, 200
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				bp.Enqueue(span)
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, nil, bp.Shutdown(context.Background()))
+	wg.Wait()
+
+	// every span is either exported by the drain or counted as dropped
+	_, spans := exp.count()
+	assert.Equal(t, workers*perWorker, spans+int(bp.Dropped()))
+}
+
+func TestReplaceExporter(t *testing.T) {
+	exp := &memoryExporter{}
+	btrace.ReplaceExporter(exp, btrace.BatchConfig{Interval: time.Hour})
+	defer btrace.ReplaceExporter(nil)
+
+	ctx := bcontext.New()
+	_, parent := btrace.StartSpan(ctx, "parent")
+	_, child := btrace.StartSpan(ctx, "child")
+	child.End()
+	parent.End()
+
+	assert.Equal(t, nil, btrace.FlushExporter(context.Background()))
+	_, spans := exp.count()
+	assert.Equal(t, 2, spans)
+
+	assert.Equal(t, nil, btrace.ShutdownExporter(context.Background()))
+	assert.Equal(t, true, exp.shutdown)
+}
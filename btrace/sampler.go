@@ -0,0 +1,260 @@
+package btrace
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lamber92/go-brick/bcontext"
+	bsync "github.com/lamber92/go-brick/internal/sync"
+)
+
+const (
+	KeySampling = bcontext.TraceSampling
+)
+
+// SamplingParameters the information a sampling decision is based on
+type SamplingParameters struct {
+	// Parent the span context in the context, invalid for a new trace.
+	// it is usually propagated from the upstream.
+	Parent SpanContext
+	// TraceID the trace to be sampled
+	TraceID TraceID
+	// Name the name of the first span or the module of the first metadata
+	Name string
+}
+
+// Sampler decide whether a trace is recorded.
+// the decision is made once at the root of the trace and carried in the context,
+// all the spans and metadata of the trace follow it.
+type Sampler interface {
+	// ShouldSample return 'true' if the trace should be recorded
+	ShouldSample(p SamplingParameters) bool
+	// Description return the name and the options of the sampler
+	Description() string
+}
+
+// ErrorSampler is implemented by samplers that let an error upgrade an unsampled trace to sampled
+type ErrorSampler interface {
+	Sampler
+	// SampleOnError return 'true' if errors upgrade the trace
+	SampleOnError() bool
+}
+
+var sampler Sampler = AlwaysSample()
+
+// ReplaceSampler overrides the default sampler, which records every trace.
+// nb. this function is not thread-safe, call it when you initialize the program.
+func ReplaceSampler(s Sampler) {
+	if s == nil {
+		s = AlwaysSample()
+	}
+	sampler = s
+}
+
+// IsSampled report whether the trace in ctx is recorded.
+// a context without a sampling decision is regarded as sampled.
+func IsSampled(ctx context.Context) bool {
+	if d := decisionFromCtx(ctx); d != nil {
+		return d.sampled.Load()
+	}
+	return true
+}
+
+// SampleOnError upgrade the trace in ctx to sampled if the sampler allows errors to do so.
+// Span.SetError does it implicitly, call it for errors recorded outside of a span.
+// returns 'true' if the trace is sampled afterwards.
+func SampleOnError(ctx context.Context) bool {
+	d := decisionFromCtx(ctx)
+	if d == nil {
+		return true
+	}
+	if d.onError {
+		d.sampled.Store(true)
+	}
+	return d.sampled.Load()
+}
+
+// samplingDecision shared by all the spans of a trace in the same process.
+// the pointer is carried in the context, so that an upgrade is visible to all of them.
+type samplingDecision struct {
+	sampled atomic.Bool
+	onError bool
+}
+
+func newSamplingDecision(p SamplingParameters) *samplingDecision {
+	d := &samplingDecision{}
+	d.sampled.Store(sampler.ShouldSample(p))
+	if tmp, ok := sampler.(ErrorSampler); ok {
+		d.onError = tmp.SampleOnError()
+	}
+	return d
+}
+
+func decisionFromCtx(ctx context.Context) *samplingDecision {
+	d, _ := ctx.Value(KeySampling).(*samplingDecision)
+	return d
+}
+
+// ensureDecision make the sampling decision at the root of the trace.
+// the decision is stored into bcontext.Context only, a plain context.Context gets a derived one.
+func ensureDecision(ctx context.Context, p SamplingParameters) (context.Context, *samplingDecision) {
+	if d := decisionFromCtx(ctx); d != nil {
+		return ctx, d
+	}
+	d := newSamplingDecision(p)
+	switch tmp := ctx.(type) {
+	case bcontext.Context:
+		tmp.Set(KeySampling, d)
+		return tmp, d
+	default:
+		return context.WithValue(ctx, KeySampling, d), d
+	}
+}
+
+// =======================================
+// -------- Built-in Sampler IMPL --------
+// =======================================
+
+type alwaysSampler bool
+
+// AlwaysSample record every trace
+func AlwaysSample() Sampler {
+	return alwaysSampler(true)
+}
+
+// NeverSample record nothing, unless it is wrapped by AlwaysOnError
+func NeverSample() Sampler {
+	return alwaysSampler(false)
+}
+
+func (s alwaysSampler) ShouldSample(SamplingParameters) bool {
+	return bool(s)
+}
+
+func (s alwaysSampler) Description() string {
+	if s {
+		return "AlwaysOn"
+	}
+	return "AlwaysOff"
+}
+
+type ratioSampler struct {
+	ratio float64
+	bound uint64
+}
+
+// RatioSample record the given fraction of traces.
+// the decision is derived from the trace-id, so every service on the same trace makes the same one.
+func RatioSample(ratio float64) Sampler {
+	if ratio >= 1 {
+		return AlwaysSample()
+	}
+	if ratio <= 0 {
+		ratio = 0
+	}
+	return &ratioSampler{
+		ratio: ratio,
+		bound: uint64(ratio * (1 << 63)),
+	}
+}
+
+func (s *ratioSampler) ShouldSample(p SamplingParameters) bool {
+	// the default trace-id is a uuid v4 with fixed version and variant bits,
+	// hash it instead of taking the raw bytes.
+	h := fnv.New64a()
+	_, _ = h.Write(p.TraceID[:])
+	return h.Sum64()>>1 < s.bound
+}
+
+func (s *ratioSampler) Description() string {
+	return fmt.Sprintf("Ratio{%g}", s.ratio)
+}
+
+type rateLimitedSampler struct {
+	perSecond float64
+	capacity  float64
+	tokens    float64
+	last      time.Time
+	sync.Locker
+}
+
+// RateLimitedSample record at most perSecond traces per second (token bucket)
+func RateLimitedSample(perSecond float64) Sampler {
+	if perSecond <= 0 {
+		return NeverSample()
+	}
+	capacity := perSecond
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &rateLimitedSampler{
+		perSecond: perSecond,
+		capacity:  capacity,
+		tokens:    capacity,
+		last:      time.Now(),
+		Locker:    bsync.NewSpinLock(),
+	}
+}
+
+func (s *rateLimitedSampler) ShouldSample(SamplingParameters) bool {
+	s.Lock()
+	defer s.Unlock()
+	now := time.Now()
+	s.tokens += now.Sub(s.last).Seconds() * s.perSecond
+	if s.tokens > s.capacity {
+		s.tokens = s.capacity
+	}
+	s.last = now
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
+
+func (s *rateLimitedSampler) Description() string {
+	return fmt.Sprintf("RateLimited{%g}", s.perSecond)
+}
+
+type parentBasedSampler struct {
+	root Sampler
+}
+
+// ParentBased follow the decision of the parent propagated from the upstream,
+// the root sampler decides for new traces.
+func ParentBased(root Sampler) Sampler {
+	return &parentBasedSampler{root: root}
+}
+
+func (s *parentBasedSampler) ShouldSample(p SamplingParameters) bool {
+	if p.Parent.IsValid() {
+		return p.Parent.IsSampled()
+	}
+	return s.root.ShouldSample(p)
+}
+
+func (s *parentBasedSampler) Description() string {
+	return "ParentBased{root:" + s.root.Description() + "}"
+}
+
+type alwaysOnErrorSampler struct {
+	Sampler
+}
+
+// AlwaysOnError let errors upgrade the traces that the sampler does not record,
+// so that failures are always recorded from the point they happen.
+func AlwaysOnError(s Sampler) ErrorSampler {
+	return &alwaysOnErrorSampler{Sampler: s}
+}
+
+func (s *alwaysOnErrorSampler) SampleOnError() bool {
+	return true
+}
+
+func (s *alwaysOnErrorSampler) Description() string {
+	return "AlwaysOnError{" + s.Sampler.Description() + "}"
+}
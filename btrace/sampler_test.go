@@ -0,0 +1,151 @@
+package btrace_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/btrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatioSample(t *testing.T) {
+	s := btrace.RatioSample(0.5)
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		tid, _ := btrace.TraceIDFromHex(btrace.GenTraceID())
+		if s.ShouldSample(btrace.SamplingParameters{TraceID: tid}) {
+			sampled++
+		}
+	}
+	assert.InDelta(t, 5000, sampled, 500)
+
+	// the decision is stable for the same trace
+	tid, _ := btrace.TraceIDFromHex(btrace.GenTraceID())
+	p := btrace.SamplingParameters{TraceID: tid}
+	assert.Equal(t, s.ShouldSample(p), s.ShouldSample(p))
+
+	assert.Equal(t, "AlwaysOn", btrace.RatioSample(1).Description())
+	assert.Equal(t, false, btrace.RatioSample(0).ShouldSample(p))
+}
+
+func TestRateLimitedSample(t *testing.T) {
+	s := btrace.RateLimitedSample(2)
+	assert.Equal(t, true, s.ShouldSample(btrace.SamplingParameters{}))
+	assert.Equal(t, true, s.ShouldSample(btrace.SamplingParameters{}))
+	assert.Equal(t, false, s.ShouldSample(btrace.SamplingParameters{}))
+}
+
+func TestParentBased(t *testing.T) {
+	s := btrace.ParentBased(btrace.NeverSample())
+	assert.Equal(t, false, s.ShouldSample(btrace.SamplingParameters{}))
+
+	parent, _ := btrace.ParseTraceParent(testTraceParent)
+	assert.Equal(t, true, s.ShouldSample(btrace.SamplingParameters{Parent: parent}))
+	parent.Flags = parent.Flags.WithSampled(false)
+	assert.Equal(t, false, btrace.ParentBased(btrace.AlwaysSample()).ShouldSample(btrace.SamplingParameters{Parent: parent}))
+	assert.Equal(t, "ParentBased{root:AlwaysOff}", s.Description())
+}
+
+func TestUnsampledTrace(t *testing.T) {
+	btrace.ReplaceSampler(btrace.NeverSample())
+	defer btrace.ReplaceSampler(nil)
+
+	ctx := bcontext.New()
+	_, span := btrace.StartSpan(ctx, "root")
+	assert.Equal(t, false, span.SpanContext().IsSampled())
+	assert.Equal(t, false, btrace.IsSampled(ctx))
+	assert.Equal(t, false, btrace.AppendMDIntoCtx(ctx, btrace.NewMD(testMod1, "test metadata 1")))
+	span.SetError(berror.NewNotFound(nil, "not found"))
+	span.End()
+	_, ok := btrace.GetMDFromCtx(ctx)
+	assert.Equal(t, false, ok)
+
+	// the downstream is told not to record either
+	carrier := btrace.MapCarrier{}
+	btrace.Inject(ctx, carrier)
+	assert.Contains(t, carrier.Get(btrace.HeaderTraceParent), "-00")
+
+	// the decision is made once at the root, without any span
+	ctx2 := bcontext.New()
+	assert.Equal(t, false, btrace.AppendMDIntoCtx(ctx2, btrace.NewMD(testMod1, "test metadata 1")))
+	btrace.ReplaceSampler(btrace.AlwaysSample())
+	assert.Equal(t, false, btrace.AppendMDIntoCtx(ctx2, btrace.NewMD(testMod1, "test metadata 1")))
+}
+
+func TestSampleOnError(t *testing.T) {
+	btrace.ReplaceSampler(btrace.AlwaysOnError(btrace.NeverSample()))
+	defer btrace.ReplaceSampler(nil)
+
+	ctx := bcontext.New()
+	_, root := btrace.StartSpan(ctx, "root")
+	btrace.AppendMDIntoCtx(ctx, btrace.NewMD(testMod1, "dropped"))
+	_, child := btrace.StartSpan(ctx, "child")
+	assert.Equal(t, false, child.SpanContext().IsSampled())
+
+	// the error upgrades the whole trace
+	child.SetError(berror.NewInternalError(nil, "failed"))
+	assert.Equal(t, true, child.SpanContext().IsSampled())
+	assert.Equal(t, true, root.SpanContext().IsSampled())
+	assert.Equal(t, true, btrace.IsSampled(ctx))
+	btrace.AppendMDIntoCtx(ctx, btrace.NewMD(testMod2, "recorded"))
+	child.End()
+	root.End()
+
+	chain, ok := btrace.GetMDFromCtx(ctx)
+	assert.Equal(t, true, ok)
+	modules := make([]btrace.Module, 0)
+	for _, md := range chain.Get() {
+		modules = append(modules, md.Module())
+	}
+	assert.Equal(t, []btrace.Module{testMod2, "child", "root"}, modules)
+
+	// a plain context carries the decision as well
+	std, span := btrace.StartSpan(context.Background(), "std")
+	assert.Equal(t, false, btrace.IsSampled(std))
+	assert.Equal(t, true, btrace.SampleOnError(std))
+	span.End()
+}
+
+type countSampler struct {
+	calls int
+}
+
+func (s *countSampler) ShouldSample(p btrace.SamplingParameters) bool {
+	s.calls++
+	return true
+}
+
+func (s *countSampler) Description() string {
+	return "CountSampler"
+}
+
+type providerCtxKey struct{}
+
+// deriveProvider put its own span into a derived context, like botel does
+type deriveProvider struct{}
+
+func (deriveProvider) Start(ctx context.Context, parent btrace.SpanContext, name string, attrs []btrace.Attribute) (context.Context, btrace.SpanContext, btrace.SpanPeer) {
+	return context.WithValue(ctx, providerCtxKey{}, name), btrace.NewRootSpanContext(ctx), nil
+}
+
+func TestSampleWithSpanProvider(t *testing.T) {
+	sampler := &countSampler{}
+	btrace.ReplaceSampler(sampler)
+	defer btrace.ReplaceSampler(nil)
+	btrace.ReplaceSpanProvider(deriveProvider{})
+	defer btrace.ReplaceSpanProvider(nil)
+
+	// the decision is kept by the bcontext, not only by the derived context
+	ctx := bcontext.New()
+	_, span := btrace.StartSpan(ctx, "root")
+	assert.Equal(t, true, btrace.IsSampled(ctx))
+	span.End()
+	assert.Equal(t, 1, sampler.calls)
+
+	chain, ok := btrace.GetMDFromCtx(ctx)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, len(chain.Get()))
+	assert.Equal(t, btrace.Module("root"), chain.Get()[0].Module())
+}
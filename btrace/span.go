@@ -0,0 +1,420 @@
+package btrace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/berror/bstatus"
+	"github.com/lamber92/go-brick/internal/bufferpool"
+	"github.com/lamber92/go-brick/internal/json"
+	bsync "github.com/lamber92/go-brick/internal/sync"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	KeySpan = bcontext.TraceSpan
+)
+
+// Attribute key-value pair describing a span or an event
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Attr construct an Attribute
+func Attr(key string, value any) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// ValueString return the value in string form
+func (a Attribute) ValueString() string {
+	switch tmp := a.Value.(type) {
+	case string:
+		return tmp
+	case []byte:
+		return string(tmp)
+	case error:
+		return berror.RedactError(tmp)
+	case fmt.Stringer:
+		return tmp.String()
+	default:
+		out, _ := json.MarshalToString(tmp)
+		return out
+	}
+}
+
+// Event a time-stamped annotation of a span
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes []Attribute
+}
+
+// Span a timed operation in a trace.
+// Span is also a Metadata, when it ends, it is appended to the chain of the context where it started.
+type Span interface {
+	Metadata
+	// Name return the span name
+	Name() string
+	// SpanContext return the identity of the span
+	SpanContext() SpanContext
+	// SetAttributes add or overwrite attributes
+	SetAttributes(attrs ...Attribute)
+	// Attributes return a copy of the attributes
+	Attributes() []Attribute
+	// AddEvent add a time-stamped event
+	AddEvent(name string, attrs ...Attribute)
+	// Events return a copy of the events
+	Events() []Event
+	// SetError record the error, the span status is derived from the berror code.
+	// a nil error does not reset the status.
+	// an unsampled trace is upgraded to sampled if the sampler is wrapped by AlwaysOnError.
+	SetError(err error)
+	// Err return the recorded error
+	Err() error
+	// Status return the span status, bstatus.OK if no error has been recorded
+	Status() bstatus.Status
+	// StartTime return the start time
+	StartTime() time.Time
+	// EndTime return the end time, zero if the span has not ended
+	EndTime() time.Time
+	// Duration return the elapsed time, up to now if the span has not ended
+	Duration() time.Duration
+	// End finish the span. only the first call takes effect.
+	// the span is appended to the chain and exported only if the trace is sampled.
+	End()
+	// Peer return the mirror of the span in another tracing system, nil if there is none.
+	// see ReplaceSpanProvider.
+	Peer() SpanPeer
+}
+
+// StartSpan start a new span as the child of the span in ctx,
+// or as a root span if ctx does not carry a span context.
+//
+// nb. bcontext.Context is mutable, the span becomes the current span of the same context
+// and the parent is restored when the span ends.
+// other context.Context implementations get a derived context instead.
+func StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	parent, hasParent := GetSpanContext(ctx)
+	var (
+		sc   SpanContext
+		peer SpanPeer
+		out  = ctx
+	)
+	if spanProvider != nil {
+		out, sc, peer = spanProvider.Start(ctx, parent, name, attrs)
+	} else if hasParent && parent.IsValid() {
+		sc = parent.NewChild()
+		if parent.Remote {
+			// the span context prepared by Extract is never recorded as a span,
+			// the remote span is the real parent.
+			sc.ParentSpanID = parent.ParentSpanID
+		}
+	} else {
+		sc = NewRootSpanContext(ctx)
+	}
+	out, decision := ensureDecision(out, SamplingParameters{Parent: parent, TraceID: sc.TraceID, Name: name})
+	sc.Flags = sc.Flags.WithSampled(decision.sampled.Load())
+	s := &defaultSpan{
+		name:      name,
+		sc:        sc,
+		peer:      peer,
+		decision:  decision,
+		start:     time.Now(),
+		ctx:       ctx,
+		parent:    parent,
+		hasParent: hasParent,
+		Locker:    bsync.NewSpinLock(),
+	}
+	if len(attrs) > 0 {
+		s.attrs = append(make([]Attribute, 0, len(attrs)), attrs...)
+	}
+	if tmp, ok := ctx.(bcontext.Context); ok {
+		// the decision may have been stored into the context derived by the span provider
+		if decisionFromCtx(tmp) == nil {
+			tmp.Set(KeySampling, decision)
+		}
+		s.prevSpan = tmp.Value(KeySpan)
+		tmp.Set(KeySpan, s)
+		out = tmp
+	} else {
+		out = context.WithValue(out, KeySpan, s)
+	}
+	return SetSpanContext(out, sc), s
+}
+
+// SpanFromCtx get the current span from context
+func SpanFromCtx(ctx context.Context) (Span, bool) {
+	s, ok := ctx.Value(KeySpan).(Span)
+	return s, ok
+}
+
+type defaultSpan struct {
+	name     string
+	sc       SpanContext
+	attrs    []Attribute
+	events   []Event
+	peer     SpanPeer
+	decision *samplingDecision
+	err      error
+	status   bstatus.Status
+	start    time.Time
+	end      time.Time
+
+	// the context where the span started, used to restore the parent span
+	ctx       context.Context
+	parent    SpanContext
+	hasParent bool
+	prevSpan  any
+
+	sync.Locker
+}
+
+func (s *defaultSpan) Module() Module {
+	return Module(s.name)
+}
+
+func (s *defaultSpan) Name() string {
+	return s.name
+}
+
+func (s *defaultSpan) SpanContext() SpanContext {
+	sc := s.sc
+	sc.Flags = sc.Flags.WithSampled(s.decision.sampled.Load())
+	return sc
+}
+
+func (s *defaultSpan) SetAttributes(attrs ...Attribute) {
+	s.Lock()
+	defer s.Unlock()
+LOOP:
+	for _, attr := range attrs {
+		for idx := range s.attrs {
+			if s.attrs[idx].Key == attr.Key {
+				s.attrs[idx].Value = attr.Value
+				continue LOOP
+			}
+		}
+		s.attrs = append(s.attrs, attr)
+	}
+	if s.peer != nil {
+		s.peer.SetAttributes(attrs...)
+	}
+}
+
+func (s *defaultSpan) Attributes() []Attribute {
+	s.Lock()
+	out := make([]Attribute, 0, len(s.attrs))
+	out = append(out, s.attrs...)
+	s.Unlock()
+	return out
+}
+
+func (s *defaultSpan) AddEvent(name string, attrs ...Attribute) {
+	now := time.Now()
+	s.Lock()
+	s.events = append(s.events, Event{Name: name, Time: now, Attributes: attrs})
+	s.Unlock()
+	if s.peer != nil {
+		s.peer.AddEvent(name, now, attrs...)
+	}
+}
+
+func (s *defaultSpan) Events() []Event {
+	s.Lock()
+	out := make([]Event, 0, len(s.events))
+	out = append(out, s.events...)
+	s.Unlock()
+	return out
+}
+
+func (s *defaultSpan) SetError(err error) {
+	if err == nil {
+		return
+	}
+	status := statusFromError(err)
+	if s.decision.onError {
+		s.decision.sampled.Store(true)
+	}
+	s.Lock()
+	s.err = err
+	s.status = status
+	s.Unlock()
+	if s.peer != nil {
+		s.peer.SetError(err, status)
+	}
+}
+
+func (s *defaultSpan) Err() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.err
+}
+
+func (s *defaultSpan) Status() bstatus.Status {
+	s.Lock()
+	defer s.Unlock()
+	if s.status == nil {
+		return bstatus.OK
+	}
+	return s.status
+}
+
+func (s *defaultSpan) StartTime() time.Time {
+	return s.start
+}
+
+func (s *defaultSpan) EndTime() time.Time {
+	s.Lock()
+	defer s.Unlock()
+	return s.end
+}
+
+func (s *defaultSpan) Duration() time.Duration {
+	s.Lock()
+	defer s.Unlock()
+	if s.end.IsZero() {
+		return time.Since(s.start)
+	}
+	return s.end.Sub(s.start)
+}
+
+func (s *defaultSpan) End() {
+	s.Lock()
+	if !s.end.IsZero() {
+		s.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.Unlock()
+	if s.peer != nil {
+		s.peer.End(s.end)
+	}
+
+	// restore the parent span of the mutable context,
+	// unless another span has taken over in the meantime.
+	if tmp, ok := s.ctx.(bcontext.Context); ok {
+		if cur, exist := tmp.Get(KeySpan); exist && cur == Span(s) {
+			tmp.Set(KeySpan, s.prevSpan)
+			if s.hasParent {
+				tmp.Set(KeySpanContext, s.parent)
+			} else {
+				tmp.Set(KeySpanContext, nil)
+			}
+		}
+	}
+	if s.decision.sampled.Load() {
+		AppendMDIntoCtx(s.ctx, s)
+		exportSpan(s)
+	}
+}
+
+func (s *defaultSpan) Peer() SpanPeer {
+	return s.peer
+}
+
+// String formatted output, the redactors of the module are applied to the attributes
+func (s *defaultSpan) String() string {
+	return s.redactedString(getRedactors(s.Module()))
+}
+
+func (s *defaultSpan) redactedString(rs []Redactor) string {
+	s.Lock()
+	defer s.Unlock()
+	buff := bufferpool.Get()
+	buff.AppendString("module: ")
+	buff.AppendString(s.name)
+	for _, attr := range s.attrs {
+		buff.AppendString(" | ")
+		buff.AppendString(attr.Key)
+		buff.AppendString(": ")
+		buff.AppendString(redactString(rs, attr.Key, attr.ValueString()))
+	}
+	buff.AppendString(" | span_id: ")
+	buff.AppendString(s.sc.SpanID.String())
+	if s.sc.HasParent() {
+		buff.AppendString(" | parent_id: ")
+		buff.AppendString(s.sc.ParentSpanID.String())
+	}
+	buff.AppendString(" | cost: ")
+	buff.AppendInt(s.costMillis())
+	if s.err != nil {
+		buff.AppendString(" | code: ")
+		buff.AppendInt(int64(s.status.Code().ToInt()))
+		buff.AppendString(" | err: ")
+		buff.AppendString(berror.RedactError(s.err))
+	}
+	out := buff.String()
+	buff.Free()
+	return out
+}
+
+// MarshalLogObject zapcore.ObjectMarshaler impl
+func (s *defaultSpan) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	s.Lock()
+	defer s.Unlock()
+	enc.AddString("module", s.name)
+	for _, attr := range s.attrs {
+		zap.Any(attr.Key, attr.Value).AddTo(enc)
+	}
+	if len(s.events) > 0 {
+		_ = enc.AddArray("events", eventList(s.events))
+	}
+	enc.AddString("span_id", s.sc.SpanID.String())
+	if s.sc.HasParent() {
+		enc.AddString("parent_id", s.sc.ParentSpanID.String())
+	}
+	enc.AddInt64("cost", s.costMillis())
+	if s.err == nil {
+		enc.AddString("err", "")
+	} else {
+		enc.AddInt("code", s.status.Code().ToInt())
+		enc.AddString("err", berror.RedactError(s.err))
+	}
+	return nil
+}
+
+// costMillis the caller must hold the lock
+func (s *defaultSpan) costMillis() int64 {
+	if s.end.IsZero() {
+		return time.Since(s.start).Milliseconds()
+	}
+	return s.end.Sub(s.start).Milliseconds()
+}
+
+type eventList []Event
+
+func (l eventList) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, v := range l {
+		if err := enc.AppendObject(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e Event) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", e.Name)
+	enc.AddInt64("time", e.Time.UnixMilli())
+	for _, attr := range e.Attributes {
+		zap.Any(attr.Key, attr.Value).AddTo(enc)
+	}
+	return nil
+}
+
+// statusFromError derive the span status from the berror code,
+// errors that are not berror.Error are regarded as bcode.Unknown.
+func statusFromError(err error) bstatus.Status {
+	var e berror.Error
+	if errors.As(err, &e) {
+		return bstatus.New(e.Status().Code(), e.Status().Reason(), nil)
+	}
+	return bstatus.New(bcode.Unknown, err.Error(), nil)
+}
@@ -0,0 +1,58 @@
+package btrace
+
+import (
+	"context"
+	"time"
+
+	"github.com/lamber92/go-brick/berror/bstatus"
+)
+
+// SpanPeer mirror a btrace span into another tracing system, such as OpenTelemetry.
+// all the calls happen after the btrace span has recorded the same data.
+type SpanPeer interface {
+	// SetAttributes add or overwrite attributes
+	SetAttributes(attrs ...Attribute)
+	// AddEvent add a time-stamped event
+	AddEvent(name string, t time.Time, attrs ...Attribute)
+	// SetError record the error and the status derived from it
+	SetError(err error, status bstatus.Status)
+	// End finish the peer span
+	End(t time.Time)
+}
+
+// SpanProvider take over the identity of new spans from btrace,
+// so that the spans also live in another tracing system.
+type SpanProvider interface {
+	// Start begin a peer span.
+	// parent is the span context found in ctx, check parent.IsValid() before use.
+	// it returns the identity of the new span and its peer.
+	// the returned context replaces ctx only if ctx is not a bcontext.Context,
+	// which allows the provider to put its own span into a derived context.
+	Start(ctx context.Context, parent SpanContext, name string, attrs []Attribute) (context.Context, SpanContext, SpanPeer)
+}
+
+// SpanContextReader is optionally implemented by a SpanProvider,
+// to read the span context of its own span carried by a context.Context.
+type SpanContextReader interface {
+	// SpanContextFromContext return the span context of the peer span in ctx, if it is valid
+	SpanContextFromContext(ctx context.Context) (SpanContext, bool)
+}
+
+var spanProvider SpanProvider
+
+// ReplaceSpanProvider overrides the way spans are created.
+// a nil provider restores the native btrace spans.
+// nb. this function is not thread-safe, call it when you initialize the program.
+func ReplaceSpanProvider(p SpanProvider) {
+	spanProvider = p
+}
+
+// GetPeerSpanContext get the span context of the peer span in ctx through the SpanProvider,
+// such as an OTel span started by other instrumented libraries.
+// returns 'false' if there is none or the provider does not implement SpanContextReader.
+func GetPeerSpanContext(ctx context.Context) (SpanContext, bool) {
+	if r, ok := spanProvider.(SpanContextReader); ok {
+		return r.SpanContextFromContext(ctx)
+	}
+	return SpanContext{}, false
+}
@@ -0,0 +1,77 @@
+package btrace_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/btrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartSpan(t *testing.T) {
+	ctx := bcontext.New()
+
+	_, root := btrace.StartSpan(ctx, "root", btrace.Attr("k1", "v1"))
+	rootSC := root.SpanContext()
+	assert.Equal(t, true, rootSC.IsValid())
+	assert.Equal(t, false, rootSC.HasParent())
+	cur, ok := btrace.SpanFromCtx(ctx)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, root, cur)
+
+	_, child := btrace.StartSpan(ctx, "child")
+	childSC := child.SpanContext()
+	assert.Equal(t, rootSC.TraceID, childSC.TraceID)
+	assert.Equal(t, rootSC.SpanID, childSC.ParentSpanID)
+	assert.Equal(t, rootSC.TraceID.String(), btrace.GetTraceID(ctx))
+
+	child.SetAttributes(btrace.Attr("k2", 1), btrace.Attr("k2", 2))
+	child.AddEvent("retry", btrace.Attr("times", 1))
+	child.SetError(berror.NewNotFound(nil, "not found"))
+	child.End()
+	child.End()
+	assert.Equal(t, []btrace.Attribute{btrace.Attr("k2", 2)}, child.Attributes())
+	assert.Equal(t, 1, len(child.Events()))
+	assert.Equal(t, bcode.NotFound, child.Status().Code())
+	assert.Equal(t, false, child.EndTime().IsZero())
+
+	// the parent is restored after the child ends
+	cur, _ = btrace.SpanFromCtx(ctx)
+	assert.Equal(t, root, cur)
+	sc, _ := btrace.GetSpanContext(ctx)
+	assert.Equal(t, rootSC, sc)
+
+	root.SetError(errors.New("raw error"))
+	root.End()
+	assert.Equal(t, bcode.Unknown, root.Status().Code())
+
+	// spans are appended to the chain when they end
+	chain, ok := btrace.GetMDFromCtx(ctx)
+	assert.Equal(t, true, ok)
+	list := chain.Get()
+	assert.Equal(t, 2, len(list))
+	assert.Equal(t, btrace.Module("child"), list[0].Module())
+	assert.Equal(t, btrace.Module("root"), list[1].Module())
+	t.Log(chain.String())
+}
+
+func TestStartSpanWithStdContext(t *testing.T) {
+	ctx, parent := btrace.StartSpan(context.Background(), "parent")
+	ctx2, child := btrace.StartSpan(ctx, "child")
+	assert.Equal(t, parent.SpanContext().SpanID, child.SpanContext().ParentSpanID)
+
+	cur, _ := btrace.SpanFromCtx(ctx)
+	assert.Equal(t, parent, cur)
+	cur, _ = btrace.SpanFromCtx(ctx2)
+	assert.Equal(t, child, cur)
+
+	child.End()
+	parent.End()
+	assert.Equal(t, bcode.OK, parent.Status().Code())
+	_, ok := btrace.GetMDFromCtx(ctx)
+	assert.Equal(t, false, ok)
+}
@@ -0,0 +1,252 @@
+package btrace
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/lamber92/go-brick/internal/bufferpool"
+	"go.uber.org/zap/zapcore"
+)
+
+// ModuleSummary the aggregation of the metadata of a module
+type ModuleSummary struct {
+	Module Module
+	// Count the number of metadata
+	Count int
+	// Errors the number of metadata that carry an error
+	Errors int
+	// TotalCost the sum of the durations
+	TotalCost time.Duration
+	// MaxCost the longest duration
+	MaxCost time.Duration
+}
+
+// MarshalLogObject zapcore.ObjectMarshaler impl
+func (m *ModuleSummary) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("module", string(m.Module))
+	enc.AddInt("count", m.Count)
+	enc.AddInt("errors", m.Errors)
+	enc.AddInt64("total_cost", m.TotalCost.Milliseconds())
+	enc.AddInt64("max_cost", m.MaxCost.Milliseconds())
+	return nil
+}
+
+type moduleSummaryList []*ModuleSummary
+
+func (l moduleSummaryList) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, v := range l {
+		if err := enc.AppendObject(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Summary the aggregation of the trace chain of a request.
+// String() renders a compact tree for the console, MarshalLogObject renders an object for JSON.
+type Summary struct {
+	TraceID string
+	// Entries the number of metadata in the chain
+	Entries int
+	// Dropped the number of metadata dropped by the chain limits
+	Dropped int
+	// Errors the number of metadata that carry an error
+	Errors int
+	// Elapsed from the start of the first span to the end of the last span
+	Elapsed time.Duration
+	// Modules in the order of first appearance
+	Modules []*ModuleSummary
+
+	roots []*spanNode
+	start time.Time
+}
+
+type spanNode struct {
+	span     Span
+	children []*spanNode
+}
+
+// Summarize aggregate the trace chain in ctx by module.
+// the duration and the error of a metadata come from its Duration() and Err() methods if present, as Span does.
+func Summarize(ctx context.Context) *Summary {
+	out := &Summary{TraceID: GetTraceID(ctx)}
+	chain, ok := GetMDFromCtx(ctx)
+	if !ok {
+		return out
+	}
+	mds := chain.Get()
+	out.Entries = len(mds)
+	out.Dropped = chain.Dropped()
+
+	var (
+		modules = make(map[Module]*ModuleSummary)
+		spans   = make([]Span, 0, len(mds))
+		end     time.Time
+	)
+	for _, md := range mds {
+		ms, exist := modules[md.Module()]
+		if !exist {
+			ms = &ModuleSummary{Module: md.Module()}
+			modules[md.Module()] = ms
+			out.Modules = append(out.Modules, ms)
+		}
+		ms.Count++
+		if tmp, ok := md.(interface{ Duration() time.Duration }); ok {
+			cost := tmp.Duration()
+			ms.TotalCost += cost
+			if cost > ms.MaxCost {
+				ms.MaxCost = cost
+			}
+		}
+		if tmp, ok := md.(interface{ Err() error }); ok && tmp.Err() != nil {
+			ms.Errors++
+			out.Errors++
+		}
+		if span, ok := md.(Span); ok {
+			spans = append(spans, span)
+			if out.start.IsZero() || span.StartTime().Before(out.start) {
+				out.start = span.StartTime()
+			}
+			if tmp := span.EndTime(); tmp.After(end) {
+				end = tmp
+			}
+		}
+	}
+	if len(spans) > 0 && end.After(out.start) {
+		out.Elapsed = end.Sub(out.start)
+	}
+	out.roots = buildSpanTree(spans)
+	return out
+}
+
+// buildSpanTree link the spans by their parent span-id, the spans whose parent is not in the chain are roots.
+// siblings are ordered by start time.
+func buildSpanTree(spans []Span) []*spanNode {
+	nodes := make(map[SpanID]*spanNode, len(spans))
+	for _, s := range spans {
+		nodes[s.SpanContext().SpanID] = &spanNode{span: s}
+	}
+	roots := make([]*spanNode, 0)
+	for _, s := range spans {
+		node := nodes[s.SpanContext().SpanID]
+		if parent, ok := nodes[s.SpanContext().ParentSpanID]; ok && s.SpanContext().HasParent() && parent != node {
+			parent.children = append(parent.children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	var sortNodes func(list []*spanNode)
+	sortNodes = func(list []*spanNode) {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].span.StartTime().Before(list[j].span.StartTime())
+		})
+		for _, n := range list {
+			sortNodes(n.children)
+		}
+	}
+	sortNodes(roots)
+	return roots
+}
+
+// String render a compact summary, such as:
+//
+//	trace 4bf92f3577b34da6a3ce929d0e0e4736 | entries: 3 | errors: 1 | elapsed: 12ms
+//	  yaml_config        x1  total: 2ms  max: 2ms
+//	  rabbitmq-producer  x1  total: 9ms  max: 9ms  errors: 1
+//	  handler            x1  total: 12ms  max: 12ms
+//	  +0ms  12ms  handler
+//	  ├─ +1ms  2ms  yaml_config
+//	  └─ +3ms  9ms  rabbitmq-producer !err
+func (s *Summary) String() string {
+	buff := bufferpool.Get()
+	buff.AppendString("trace ")
+	buff.AppendString(s.TraceID)
+	buff.AppendString(" | entries: ")
+	buff.AppendInt(int64(s.Entries))
+	if s.Dropped > 0 {
+		buff.AppendString(" | dropped: ")
+		buff.AppendInt(int64(s.Dropped))
+	}
+	buff.AppendString(" | errors: ")
+	buff.AppendInt(int64(s.Errors))
+	buff.AppendString(" | elapsed: ")
+	buff.AppendString(formatMillis(s.Elapsed))
+
+	width := 0
+	for _, m := range s.Modules {
+		if len(m.Module) > width {
+			width = len(m.Module)
+		}
+	}
+	for _, m := range s.Modules {
+		buff.AppendString("\n  ")
+		buff.AppendString(string(m.Module))
+		appendPadding(buff.AppendByte, width-len(m.Module)+2)
+		buff.AppendByte('x')
+		buff.AppendInt(int64(m.Count))
+		buff.AppendString("  total: ")
+		buff.AppendString(formatMillis(m.TotalCost))
+		buff.AppendString("  max: ")
+		buff.AppendString(formatMillis(m.MaxCost))
+		if m.Errors > 0 {
+			buff.AppendString("  errors: ")
+			buff.AppendInt(int64(m.Errors))
+		}
+	}
+
+	var render func(nodes []*spanNode, indent string, root bool)
+	render = func(nodes []*spanNode, indent string, root bool) {
+		for idx, n := range nodes {
+			last := idx == len(nodes)-1
+			buff.AppendString("\n  ")
+			buff.AppendString(indent)
+			next := indent
+			if !root {
+				if last {
+					buff.AppendString("└─ ")
+					next += "   "
+				} else {
+					buff.AppendString("├─ ")
+					next += "│  "
+				}
+			}
+			buff.AppendByte('+')
+			buff.AppendString(formatMillis(n.span.StartTime().Sub(s.start)))
+			buff.AppendString("  ")
+			buff.AppendString(formatMillis(n.span.Duration()))
+			buff.AppendString("  ")
+			buff.AppendString(n.span.Name())
+			if n.span.Err() != nil {
+				buff.AppendString(" !err")
+			}
+			render(n.children, next, false)
+		}
+	}
+	render(s.roots, "", true)
+
+	out := buff.String()
+	buff.Free()
+	return out
+}
+
+// MarshalLogObject zapcore.ObjectMarshaler impl
+func (s *Summary) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("trace_id", s.TraceID)
+	enc.AddInt("entries", s.Entries)
+	enc.AddInt("dropped", s.Dropped)
+	enc.AddInt("errors", s.Errors)
+	enc.AddInt64("elapsed", s.Elapsed.Milliseconds())
+	return enc.AddArray("modules", moduleSummaryList(s.Modules))
+}
+
+func formatMillis(d time.Duration) string {
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
+
+func appendPadding(appendByte func(byte), n int) {
+	for i := 0; i < n; i++ {
+		appendByte(' ')
+	}
+}
@@ -0,0 +1,62 @@
+package btrace_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/btrace"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSummarize(t *testing.T) {
+	ctx := bcontext.New()
+	_, root := btrace.StartSpan(ctx, "handler")
+	for i := 0; i < 2; i++ {
+		_, span := btrace.StartSpan(ctx, "yaml_config")
+		time.Sleep(time.Millisecond * 5)
+		span.End()
+	}
+	_, producer := btrace.StartSpan(ctx, "rabbitmq-producer")
+	producer.SetError(berror.NewInternalError(nil, "failed"))
+	producer.End()
+	btrace.AppendMDIntoCtx(ctx, btrace.NewMD(testMod1, "plain metadata"))
+	root.End()
+
+	s := btrace.Summarize(ctx)
+	assert.Equal(t, btrace.GetTraceID(ctx), s.TraceID)
+	assert.Equal(t, 5, s.Entries)
+	assert.Equal(t, 1, s.Errors)
+	assert.Equal(t, root.Duration(), s.Elapsed)
+
+	assert.Equal(t, 4, len(s.Modules))
+	yaml := s.Modules[0]
+	assert.Equal(t, btrace.Module("yaml_config"), yaml.Module)
+	assert.Equal(t, 2, yaml.Count)
+	assert.LessOrEqual(t, time.Millisecond*10, yaml.TotalCost)
+	assert.LessOrEqual(t, time.Millisecond*5, yaml.MaxCost)
+	assert.Equal(t, 1, s.Modules[1].Errors)
+	assert.Equal(t, testMod1, s.Modules[2].Module)
+	assert.Equal(t, time.Duration(0), s.Modules[2].TotalCost)
+	assert.Equal(t, btrace.Module("handler"), s.Modules[3].Module)
+
+	out := s.String()
+	t.Log("\n" + out)
+	lines := strings.Split(out, "\n")
+	assert.Equal(t, 9, len(lines))
+	assert.Contains(t, lines[5], "handler")
+	assert.Contains(t, lines[6], "├─ ")
+	assert.Contains(t, lines[8], "└─ ")
+	assert.Contains(t, lines[8], "rabbitmq-producer !err")
+
+	enc := zapcore.NewMapObjectEncoder()
+	assert.Equal(t, nil, s.MarshalLogObject(enc))
+	assert.Equal(t, 5, enc.Fields["entries"])
+	assert.Equal(t, 4, len(enc.Fields["modules"].([]any)))
+
+	// nothing to summarize
+	assert.Equal(t, 0, btrace.Summarize(bcontext.New()).Entries)
+}
@@ -3,6 +3,7 @@ package btrace
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/lamber92/go-brick/bcontext"
 	"github.com/lamber92/go-brick/internal/bufferpool"
@@ -19,6 +20,8 @@ type Chain interface {
 	Get() MetadataList
 	// Clear clear the metadata chain
 	Clear()
+	// Dropped return the number of metadata dropped due to the limits
+	Dropped() int
 	// String formatted output
 	String() string
 }
@@ -32,29 +35,39 @@ type Metadata interface {
 
 type MetadataList []Metadata
 
+// MarshalLogArray the registered redactors and the entry size limit are applied here, see ChainLimits.MaxEntryBytes.
+// the metadata that do not implement zapcore.ObjectMarshaler are printed by String() if there are redactors for them.
 func (mdl MetadataList) MarshalLogArray(enc zapcore.ArrayEncoder) (err error) {
+	maxEntryBytes := chainLimits.MaxEntryBytes
 	for _, s := range mdl {
-		if x, ok := s.(interface {
-			MarshalLogObject(zapcore.ObjectEncoder) error
-		}); ok {
-			_ = enc.AppendObject(x)
-			continue
-		}
-		_ = enc.AppendReflected(s)
+		encodeEntry(s, maxEntryBytes).appendTo(enc)
 	}
 	return
 }
 
+// NewChain create a chain with the current limits, see ReplaceChainLimits
 func NewChain() Chain {
+	return NewChainWithLimits(chainLimits)
+}
+
+// NewChainWithLimits create a chain with the specified limits
+func NewChainWithLimits(limits ChainLimits) Chain {
 	return &defaultChain{
 		chain:  make([]Metadata, 0),
+		limits: limits,
 		Locker: bsync.NewSpinLock(),
 	}
 }
 
+// AppendMDIntoCtx append metadata to the chain in context.
+// nothing is done if the trace is not sampled, see ReplaceSampler.
 func AppendMDIntoCtx(ctx context.Context, md Metadata) bool {
 	switch tmp := ctx.(type) {
 	case bcontext.Context:
+		if _, d := ensureDecision(tmp, mdSamplingParameters(tmp, md)); !d.sampled.Load() {
+			return false
+		}
+		recordMDIntoPeer(ctx, md)
 		var chain Chain
 		if ptr, ok := tmp.Get(bcontext.TraceChain); !ok || ptr == nil {
 			chain = NewChain()
@@ -70,11 +83,40 @@ func AppendMDIntoCtx(ctx context.Context, md Metadata) bool {
 		tmp.Set(bcontext.TraceChain, chain)
 		return true
 	default:
-		// do nothing...
+		if IsSampled(ctx) {
+			recordMDIntoPeer(ctx, md)
+		}
 		return false
 	}
 }
 
+// mdSamplingParameters the metadata decides for the trace if no span has been started yet
+func mdSamplingParameters(ctx context.Context, md Metadata) SamplingParameters {
+	p := SamplingParameters{Name: string(md.Module())}
+	if sc, ok := GetSpanContext(ctx); ok && sc.IsValid() {
+		p.Parent = sc
+		p.TraceID = sc.TraceID
+	} else if flat := GetTraceID(ctx); len(flat) > 0 {
+		p.TraceID = genTraceIDFrom(flat)
+	}
+	return p
+}
+
+// recordMDIntoPeer the metadata becomes an event of the peer span in ctx.
+// the finished spans are also appended to the chain, they are skipped since they belong to the peer system already.
+func recordMDIntoPeer(ctx context.Context, md Metadata) {
+	if _, ok := md.(Span); ok {
+		return
+	}
+	span, ok := SpanFromCtx(ctx)
+	if !ok {
+		return
+	}
+	if peer := span.Peer(); peer != nil {
+		peer.AddEvent(string(md.Module()), time.Now(), Attr("metadata", redactedString(md)))
+	}
+}
+
 func GetMDFromCtx(ctx context.Context) (chain Chain, ok bool) {
 	ptr := ctx.Value(bcontext.TraceChain)
 	if ptr == nil {
@@ -85,16 +127,54 @@ func GetMDFromCtx(ctx context.Context) (chain Chain, ok bool) {
 }
 
 type defaultChain struct {
-	chain []Metadata
+	chain   []Metadata
+	limits  ChainLimits
+	bytes   int
+	dropped int
 	sync.Locker
 }
 
 func (d *defaultChain) Append(metadata ...Metadata) {
+	if d.limits.MaxBytes <= 0 {
+		d.Lock()
+		for _, md := range metadata {
+			d.append(md, 0)
+		}
+		d.Unlock()
+		return
+	}
+	// measure outside the lock, encoding may be expensive
+	sizes := make([]int, len(metadata))
+	for idx, md := range metadata {
+		sizes[idx] = encodeEntry(md, d.limits.MaxEntryBytes).size()
+	}
 	d.Lock()
-	d.chain = append(d.chain, metadata...)
+	for idx, md := range metadata {
+		d.append(md, sizes[idx])
+	}
 	d.Unlock()
 }
 
+// append the caller must hold the lock
+func (d *defaultChain) append(md Metadata, size int) {
+	if d.limits.MaxEntries > 0 && len(d.chain) >= d.limits.MaxEntries {
+		d.dropped++
+		return
+	}
+	if d.limits.MaxBytes > 0 && d.bytes+size > d.limits.MaxBytes {
+		d.dropped++
+		return
+	}
+	d.bytes += size
+	d.chain = append(d.chain, md)
+}
+
+func (d *defaultChain) Dropped() int {
+	d.Lock()
+	defer d.Unlock()
+	return d.dropped
+}
+
 func (d *defaultChain) Get() MetadataList {
 	d.Lock()
 	out := make([]Metadata, 0, len(d.chain))
@@ -106,6 +186,8 @@ func (d *defaultChain) Get() MetadataList {
 func (d *defaultChain) Clear() {
 	d.Lock()
 	d.chain = make([]Metadata, 0)
+	d.bytes = 0
+	d.dropped = 0
 	d.Unlock()
 }
 
@@ -116,13 +198,16 @@ func (d *defaultChain) String() string {
 		buff.AppendByte('[')
 		buff.AppendInt(int64(idx + 1))
 		buff.AppendByte(']')
-		buff.AppendByte('{')
-		buff.AppendString(v.String())
-		buff.AppendByte('}')
+		buff.AppendString(encodeEntry(v, d.limits.MaxEntryBytes).text)
 		if idx+1 < len(d.chain) {
 			buff.AppendString(" --> ")
 		}
 	}
+	if d.dropped > 0 {
+		buff.AppendString(" (dropped: ")
+		buff.AppendInt(int64(d.dropped))
+		buff.AppendByte(')')
+	}
 	out := buff.String()
 	buff.Free()
 	d.Unlock()
@@ -145,14 +230,19 @@ func (d *defaultMD) Module() Module {
 	return d.module
 }
 
+// String formatted output, the redactors of the module are applied to the value
 func (d *defaultMD) String() string {
+	return d.redactedString(getRedactors(d.module))
+}
+
+func (d *defaultMD) redactedString(rs []Redactor) string {
 	buff := bufferpool.Get()
 	buff.AppendString("module: ")
 	buff.AppendString(string(d.module))
 	buff.AppendByte(',')
 	buff.AppendByte(' ')
 	buff.AppendString("value: ")
-	buff.AppendString(d.value)
+	buff.AppendString(redactString(rs, mdValueKey, d.value))
 	out := buff.String()
 	buff.Free()
 	return out
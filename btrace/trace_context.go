@@ -0,0 +1,453 @@
+package btrace
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/internal/bufferpool"
+)
+
+// W3C Trace Context
+// specification: https://www.w3.org/TR/trace-context/
+
+const (
+	KeySpanContext = bcontext.TraceContext
+
+	// HeaderTraceParent W3C traceparent header name
+	HeaderTraceParent = "traceparent"
+	// HeaderTraceState W3C tracestate header name
+	HeaderTraceState = "tracestate"
+
+	traceParentVersion = "00"
+	// version(2) + '-' + trace-id(32) + '-' + parent-id(16) + '-' + flags(2)
+	traceParentLen = 55
+	// maximum number of list-members in tracestate
+	traceStateMaxMembers = 32
+)
+
+// TraceID 128-bit trace identifier
+type TraceID [16]byte
+
+// IsValid a trace-id of all zero bytes is invalid
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// String lowercase hex format
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// TraceIDFromHex parse a 32-character lowercase hex string into TraceID
+func TraceIDFromHex(s string) (TraceID, error) {
+	var t TraceID
+	if err := decodeHexID(t[:], s); err != nil {
+		return t, berror.NewInvalidArgument(err, "invalid trace-id", s)
+	}
+	if !t.IsValid() {
+		return t, berror.NewInvalidArgument(nil, "trace-id cannot be all zero", s)
+	}
+	return t, nil
+}
+
+// SpanID 64-bit span identifier
+type SpanID [8]byte
+
+// IsValid a span-id of all zero bytes is invalid
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// String lowercase hex format
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanIDFromHex parse a 16-character lowercase hex string into SpanID
+func SpanIDFromHex(s string) (SpanID, error) {
+	var sid SpanID
+	if err := decodeHexID(sid[:], s); err != nil {
+		return sid, berror.NewInvalidArgument(err, "invalid span-id", s)
+	}
+	if !sid.IsValid() {
+		return sid, berror.NewInvalidArgument(nil, "span-id cannot be all zero", s)
+	}
+	return sid, nil
+}
+
+// decodeHexID only lowercase hex is allowed by the specification
+func decodeHexID(dst []byte, s string) error {
+	if len(s) != len(dst)*2 {
+		return fmt.Errorf("expected %d hex characters, got %d", len(dst)*2, len(s))
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return fmt.Errorf("invalid character %q at %d", c, i)
+		}
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err
+}
+
+// TraceFlags trace-flags field of traceparent
+type TraceFlags byte
+
+const (
+	// FlagsSampled the caller may have recorded trace data
+	FlagsSampled TraceFlags = 0x01
+)
+
+// IsSampled whether the sampled flag is set
+func (f TraceFlags) IsSampled() bool {
+	return f&FlagsSampled == FlagsSampled
+}
+
+// WithSampled return the flags with the sampled bit set or cleared
+func (f TraceFlags) WithSampled(sampled bool) TraceFlags {
+	if sampled {
+		return f | FlagsSampled
+	}
+	return f &^ FlagsSampled
+}
+
+// String lowercase hex format
+func (f TraceFlags) String() string {
+	return hex.EncodeToString([]byte{byte(f)})
+}
+
+// TraceState vendor-specific trace identification data.
+// the members are kept in order, the most recently updated member comes first.
+type TraceState struct {
+	members []traceStateMember
+}
+
+type traceStateMember struct {
+	key   string
+	value string
+}
+
+// ParseTraceState parse the tracestate header value.
+// invalid members are discarded, as suggested by the specification.
+func ParseTraceState(s string) TraceState {
+	var ts TraceState
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		idx := strings.IndexByte(item, '=')
+		if idx <= 0 || idx == len(item)-1 {
+			continue
+		}
+		key, value := item[:idx], item[idx+1:]
+		if !validTraceStateKey(key) || !validTraceStateValue(value) || ts.Get(key) != "" {
+			continue
+		}
+		ts.members = append(ts.members, traceStateMember{key: key, value: value})
+		if len(ts.members) == traceStateMaxMembers {
+			break
+		}
+	}
+	return ts
+}
+
+// Get return the value of the key, or an empty string if not found
+func (ts TraceState) Get(key string) string {
+	for _, m := range ts.members {
+		if m.key == key {
+			return m.value
+		}
+	}
+	return ""
+}
+
+// Insert add or update a member and move it to the front of the list
+func (ts TraceState) Insert(key, value string) (TraceState, error) {
+	if !validTraceStateKey(key) {
+		return ts, berror.NewInvalidArgument(nil, "invalid tracestate key", key)
+	}
+	if !validTraceStateValue(value) {
+		return ts, berror.NewInvalidArgument(nil, "invalid tracestate value", value)
+	}
+	out := TraceState{members: make([]traceStateMember, 0, len(ts.members)+1)}
+	out.members = append(out.members, traceStateMember{key: key, value: value})
+	for _, m := range ts.members {
+		if m.key == key {
+			continue
+		}
+		if len(out.members) == traceStateMaxMembers {
+			break
+		}
+		out.members = append(out.members, m)
+	}
+	return out, nil
+}
+
+// Delete remove a member
+func (ts TraceState) Delete(key string) TraceState {
+	out := TraceState{members: make([]traceStateMember, 0, len(ts.members))}
+	for _, m := range ts.members {
+		if m.key != key {
+			out.members = append(out.members, m)
+		}
+	}
+	return out
+}
+
+// Len number of members
+func (ts TraceState) Len() int {
+	return len(ts.members)
+}
+
+// String tracestate header format
+func (ts TraceState) String() string {
+	if len(ts.members) == 0 {
+		return ""
+	}
+	buff := bufferpool.Get()
+	for idx, m := range ts.members {
+		if idx > 0 {
+			buff.AppendByte(',')
+		}
+		buff.AppendString(m.key)
+		buff.AppendByte('=')
+		buff.AppendString(m.value)
+	}
+	out := buff.String()
+	buff.Free()
+	return out
+}
+
+// validTraceStateKey simple-key or tenant-id@system-id, lowercase only
+func validTraceStateKey(key string) bool {
+	if len(key) == 0 || len(key) > 256 {
+		return false
+	}
+	if c := key[0]; (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+		return false
+	}
+	at := false
+	for i := 1; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '_', c == '-', c == '*', c == '/':
+		case c == '@' && !at && i < len(key)-1:
+			at = true
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validTraceStateValue printable ASCII except ',' and '=', cannot end with a space
+func validTraceStateValue(value string) bool {
+	if len(value) == 0 || len(value) > 256 || value[len(value)-1] == ' ' {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c < 0x20 || c > 0x7e || c == ',' || c == '=' {
+			return false
+		}
+	}
+	return true
+}
+
+// SpanContext identity of the current span in a trace
+type SpanContext struct {
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID // zero value means that the span is the root
+	Flags        TraceFlags
+	State        TraceState
+	// Remote whether the parent span was propagated from a remote process
+	Remote bool
+}
+
+// IsValid both trace-id and span-id are valid
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// IsSampled whether the sampled flag is set
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags.IsSampled()
+}
+
+// HasParent whether the span has a parent span
+func (sc SpanContext) HasParent() bool {
+	return sc.ParentSpanID.IsValid()
+}
+
+// TraceParent format the traceparent header value,
+// the span-id of the current span is used as the parent-id of the downstream.
+func (sc SpanContext) TraceParent() string {
+	buff := bufferpool.Get()
+	buff.AppendString(traceParentVersion)
+	buff.AppendByte('-')
+	buff.AppendString(sc.TraceID.String())
+	buff.AppendByte('-')
+	buff.AppendString(sc.SpanID.String())
+	buff.AppendByte('-')
+	buff.AppendString(sc.Flags.String())
+	out := buff.String()
+	buff.Free()
+	return out
+}
+
+// NewChild derive a child span context in the same trace
+func (sc SpanContext) NewChild() SpanContext {
+	return SpanContext{
+		TraceID:      sc.TraceID,
+		SpanID:       GenSpanID(),
+		ParentSpanID: sc.SpanID,
+		Flags:        sc.Flags,
+		State:        sc.State,
+	}
+}
+
+// ParseTraceParent parse the traceparent header value.
+// the parent-id in the header is returned as SpanContext.SpanID.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+	s = strings.TrimSpace(s)
+	if len(s) < traceParentLen {
+		return sc, berror.NewInvalidArgument(nil, "invalid traceparent length", s)
+	}
+	parts := strings.SplitN(s, "-", 5)
+	if len(parts) < 4 {
+		return sc, berror.NewInvalidArgument(nil, "invalid traceparent format", s)
+	}
+	version := parts[0]
+	var ver [1]byte
+	if len(version) != 2 || version == "ff" || decodeHexID(ver[:], version) != nil {
+		return sc, berror.NewInvalidArgument(nil, "invalid traceparent version", s)
+	}
+	// version 00 must not have trailing data;
+	// future versions may append fields after flags, which are ignored.
+	if version == traceParentVersion && (len(s) != traceParentLen || len(parts) != 4) {
+		return sc, berror.NewInvalidArgument(nil, "invalid traceparent format", s)
+	}
+	var err error
+	if sc.TraceID, err = TraceIDFromHex(parts[1]); err != nil {
+		return sc, err
+	}
+	if sc.SpanID, err = SpanIDFromHex(parts[2]); err != nil {
+		return sc, err
+	}
+	var flags [1]byte
+	if err = decodeHexID(flags[:], parts[3]); err != nil {
+		return sc, berror.NewInvalidArgument(err, "invalid traceparent flags", s)
+	}
+	sc.Flags = TraceFlags(flags[0])
+	sc.Remote = true
+	return sc, nil
+}
+
+// GenSpanID generate a random span-id
+func GenSpanID() SpanID {
+	var sid SpanID
+	for !sid.IsValid() {
+		_, _ = rand.Read(sid[:])
+	}
+	return sid
+}
+
+// genTraceIDFrom
+// reuse the flat trace-id when it is a 32-character hex string (e.g. the built-in uuid v4),
+// otherwise derive one from the hash of it, so that the same flat trace-id always maps to the same trace.
+func genTraceIDFrom(flat string) TraceID {
+	if tid, err := TraceIDFromHex(flat); err == nil {
+		return tid
+	}
+	var tid TraceID
+	sum := sha256.Sum256([]byte(flat))
+	copy(tid[:], sum[:len(tid)])
+	if !tid.IsValid() {
+		tid[len(tid)-1] = 1
+	}
+	return tid
+}
+
+// NewRootSpanContext create the span context of a new trace.
+// the trace-id is taken from the flat trace-id in the context if possible, so that both stay consistent.
+func NewRootSpanContext(ctx context.Context) SpanContext {
+	flat := GetTraceID(ctx)
+	if len(flat) == 0 {
+		flat = GenTraceID()
+	}
+	return SpanContext{
+		TraceID: genTraceIDFrom(flat),
+		SpanID:  GenSpanID(),
+		Flags:   FlagsSampled,
+	}
+}
+
+// SetSpanContext set W3C span context into context.
+// the flat trace-id is kept consistent with the span context.
+func SetSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	switch tmp := ctx.(type) {
+	case bcontext.Context:
+		// keep the caller's concrete type, wrappers of bcontext.Context rely on it
+		tmp.Set(KeySpanContext, sc)
+		tmp.Set(KeyTraceID, sc.TraceID.String())
+		return tmp
+	default:
+		ctx = context.WithValue(ctx, KeySpanContext, sc)
+		return context.WithValue(ctx, KeyTraceID, sc.TraceID.String())
+	}
+}
+
+// GetSpanContext get W3C span context from context.
+// the sampled flag follows the sampling decision in the context, which may have been upgraded by an error.
+func GetSpanContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(KeySpanContext).(SpanContext)
+	if ok {
+		if d := decisionFromCtx(ctx); d != nil {
+			sc.Flags = sc.Flags.WithSampled(d.sampled.Load())
+		}
+	}
+	return sc, ok
+}
+
+// Carrier the medium that the trace context propagates through
+type Carrier interface {
+	// Get return the value associated with the key
+	Get(key string) string
+	// Set store the key-value pair
+	Set(key string, value string)
+}
+
+// Inject write the span context of ctx into the carrier.
+// if there is no span context in ctx, a root one is derived from the flat trace-id.
+func Inject(ctx context.Context, carrier Carrier) {
+	sc, ok := GetSpanContext(ctx)
+	if !ok || !sc.IsValid() {
+		sc = NewRootSpanContext(ctx)
+		sc.Flags = sc.Flags.WithSampled(IsSampled(ctx))
+	}
+	carrier.Set(HeaderTraceParent, sc.TraceParent())
+	if state := sc.State.String(); len(state) > 0 {
+		carrier.Set(HeaderTraceState, state)
+	}
+}
+
+// Extract read the span context propagated by the upstream from the carrier,
+// and set a child span context of it into ctx.
+// if the carrier does not contain a valid traceparent, ctx is returned unchanged.
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	parent, err := ParseTraceParent(carrier.Get(HeaderTraceParent))
+	if err != nil {
+		return ctx
+	}
+	parent.State = ParseTraceState(carrier.Get(HeaderTraceState))
+	child := parent.NewChild()
+	child.Remote = true
+	return SetSpanContext(ctx, child)
+}
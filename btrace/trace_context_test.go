@@ -0,0 +1,126 @@
+package btrace_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/berror/bcode"
+	"github.com/lamber92/go-brick/btrace"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	testTraceState  = "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	sc, err := btrace.ParseTraceParent(testTraceParent)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.Equal(t, true, sc.IsSampled())
+	assert.Equal(t, true, sc.Remote)
+	assert.Equal(t, testTraceParent, sc.TraceParent())
+
+	// future version with trailing data
+	sc, err = btrace.ParseTraceParent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-xxx")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, sc.IsSampled())
+
+	invalids := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xxx", // version 00 with trailing data
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",     // forbidden version
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",     // all zero trace-id
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",     // all zero parent-id
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",     // uppercase
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0x",     // invalid flags
+		"zz-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",     // non-hex version
+	}
+	for _, v := range invalids {
+		_, err = btrace.ParseTraceParent(v)
+		assert.Equal(t, true, berror.IsCode(err, bcode.InvalidArgument), v)
+	}
+}
+
+func TestTraceState(t *testing.T) {
+	ts := btrace.ParseTraceState(testTraceState + ",invalid,UPPER=x,rojo=dup")
+	assert.Equal(t, 2, ts.Len())
+	assert.Equal(t, "00f067aa0ba902b7", ts.Get("rojo"))
+	assert.Equal(t, testTraceState, ts.String())
+
+	ts, err := ts.Insert("congo", "new")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "congo=new,rojo=00f067aa0ba902b7", ts.String())
+
+	_, err = ts.Insert("Invalid", "x")
+	assert.Equal(t, true, berror.IsCode(err, bcode.InvalidArgument))
+
+	assert.Equal(t, "congo=new", ts.Delete("rojo").String())
+}
+
+func TestHTTPHeaderPropagation(t *testing.T) {
+	header := http.Header{}
+	header.Set(btrace.HeaderTraceParent, testTraceParent)
+	header.Set(btrace.HeaderTraceState, testTraceState)
+
+	ctx := btrace.ExtractHTTPHeader(bcontext.New(), header)
+	sc, ok := btrace.GetSpanContext(ctx)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.ParentSpanID.String())
+	assert.NotEqual(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.Equal(t, true, sc.IsSampled())
+	// the flat trace-id keeps working
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", btrace.GetTraceID(ctx))
+
+	// propagate to the downstream with the current span as parent
+	out := http.Header{}
+	btrace.InjectHTTPHeader(ctx, out)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+sc.SpanID.String()+"-01", out.Get(btrace.HeaderTraceParent))
+	assert.Equal(t, testTraceState, out.Get(btrace.HeaderTraceState))
+
+	// invalid header is ignored
+	bad := http.Header{}
+	bad.Set(btrace.HeaderTraceParent, "xxx")
+	ctx2 := btrace.ExtractHTTPHeader(context.Background(), bad)
+	_, ok = btrace.GetSpanContext(ctx2)
+	assert.Equal(t, false, ok)
+}
+
+func TestInjectFromFlatTraceID(t *testing.T) {
+	traceID := btrace.GenTraceID()
+	ctx := btrace.SetTraceID(bcontext.New(), traceID)
+
+	carrier := btrace.MapCarrier{}
+	btrace.Inject(ctx, carrier)
+	sc, err := btrace.ParseTraceParent(carrier.Get(btrace.HeaderTraceParent))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, traceID, sc.TraceID.String())
+	assert.Equal(t, true, sc.IsSampled())
+}
+
+func TestInjectFromCustomFlatTraceID(t *testing.T) {
+	ctx := btrace.SetTraceID(bcontext.New(), "order-20230516-0001")
+
+	// the trace-id is derived from the flat trace-id, every injection joins the same trace
+	first, second := btrace.MapCarrier{}, btrace.MapCarrier{}
+	btrace.Inject(ctx, first)
+	btrace.Inject(ctx, second)
+	sc1, err := btrace.ParseTraceParent(first.Get(btrace.HeaderTraceParent))
+	assert.Equal(t, nil, err)
+	sc2, err := btrace.ParseTraceParent(second.Get(btrace.HeaderTraceParent))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, sc1.TraceID, sc2.TraceID)
+	assert.Equal(t, true, sc1.TraceID.IsValid())
+
+	other := btrace.MapCarrier{}
+	btrace.Inject(btrace.SetTraceID(bcontext.New(), "order-20230516-0002"), other)
+	sc3, err := btrace.ParseTraceParent(other.Get(btrace.HeaderTraceParent))
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, sc1.TraceID, sc3.TraceID)
+}
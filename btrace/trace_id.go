@@ -5,12 +5,13 @@ import (
 	"encoding/hex"
 
 	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/berror"
 	uuid "github.com/satori/go.uuid"
 	"github.com/spf13/cast"
 )
 
 const (
-	KeyTraceID = "b_trace_id"
+	KeyTraceID = bcontext.TraceID
 )
 
 type TraceIDGenerator interface {
@@ -20,6 +21,11 @@ type TraceIDGenerator interface {
 
 var traceIDGen = newUUIDV4Generator()
 
+func init() {
+	// the envelopes of berror resolve the trace-id the same way
+	berror.ReplaceTraceIDFunc(GetTraceID)
+}
+
 // ReplaceTraceIDGenerator overrides the default Stack-ID generator
 func ReplaceTraceIDGenerator(gen TraceIDGenerator) {
 	traceIDGen = gen
@@ -61,12 +67,19 @@ func SetTraceID(ctx context.Context, traceIDs ...string) context.Context {
 	}
 }
 
-// GetTraceID get Stack-ID from context
+// GetTraceID get Stack-ID from context.
+// if only the W3C trace context exists, its trace-id is returned.
 func GetTraceID(ctx context.Context) string {
 	tmp := ctx.Value(KeyTraceID)
 	traceID, ok := tmp.(string)
 	if ok {
 		return traceID
 	}
-	return cast.ToString(traceID)
+	if tmp != nil {
+		return cast.ToString(tmp)
+	}
+	if sc, exist := GetSpanContext(ctx); exist {
+		return sc.TraceID.String()
+	}
+	return ""
 }
@@ -0,0 +1,429 @@
+package btrace
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lamber92/go-brick/berror"
+	"github.com/lamber92/go-brick/internal/json"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	defaultChainMaxEntries    = 128
+	defaultChainMaxBytes      = 1 << 20
+	defaultChainMaxEntryBytes = 4 << 10
+
+	redactedValue = "******"
+	mdValueKey    = "value"
+)
+
+// ChainLimits the limits of a trace chain.
+// zero value means unlimited.
+type ChainLimits struct {
+	// MaxEntries the maximum number of metadata in a chain, the rest are dropped
+	MaxEntries int
+	// MaxBytes the maximum total size of the metadata in a chain, the rest are dropped.
+	// the size of a metadata is the length of its encoded form, see MaxEntryBytes.
+	MaxBytes int
+	// MaxEntryBytes the metadata are encoded as JSON with the redactors applied,
+	// an encoded metadata longer than it is printed as a string truncated to it instead.
+	MaxEntryBytes int
+}
+
+var chainLimits = ChainLimits{
+	MaxEntries:    defaultChainMaxEntries,
+	MaxBytes:      defaultChainMaxBytes,
+	MaxEntryBytes: defaultChainMaxEntryBytes,
+}
+
+// ReplaceChainLimits overrides the limits of the chains created afterwards
+// nb. this function is not thread-safe, call it when you initialize the program.
+func ReplaceChainLimits(l ChainLimits) {
+	chainLimits = l
+}
+
+// GetChainLimits return the current limits
+func GetChainLimits() ChainLimits {
+	return chainLimits
+}
+
+// truncate cut s down to max bytes, keeping the utf-8 sequence intact
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !isRuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "...(truncated " + strconv.Itoa(len(s)-cut) + " bytes)"
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// =======================================
+// ------------- Redaction ---------------
+// =======================================
+
+// Redactor rewrite the sensitive values of metadata before they are printed.
+// path is the dot-separated key of the field, such as 'messages.body'.
+type Redactor interface {
+	// Redact return the value to print
+	Redact(path string, value string) string
+}
+
+// RedactorFunc an adapter to allow the use of ordinary functions as Redactor
+type RedactorFunc func(path string, value string) string
+
+func (f RedactorFunc) Redact(path string, value string) string {
+	return f(path, value)
+}
+
+var (
+	redactors   = make(map[Module][]Redactor)
+	redactorsMu sync.RWMutex
+)
+
+// RegisterRedactor register redactors for the metadata of the module.
+// redactors of the same module are applied in the order of registration.
+func RegisterRedactor(module Module, rs ...Redactor) {
+	redactorsMu.Lock()
+	defer redactorsMu.Unlock()
+	redactors[module] = append(redactors[module], rs...)
+}
+
+func getRedactors(module Module) []Redactor {
+	redactorsMu.RLock()
+	defer redactorsMu.RUnlock()
+	return redactors[module]
+}
+
+// redactString apply the redactors to the value at the path
+func redactString(rs []Redactor, path string, value string) string {
+	for _, r := range rs {
+		value = r.Redact(path, value)
+	}
+	return value
+}
+
+// RedactAttributes return a copy of attrs, in which the values rewritten by the redactors of the module become strings.
+// the path of a value is the key of the attribute. use it when the attributes leave the process, such as by the exporters.
+func RedactAttributes(module Module, attrs []Attribute) []Attribute {
+	rs := getRedactors(module)
+	out := make([]Attribute, 0, len(attrs))
+	for _, attr := range attrs {
+		if len(rs) > 0 {
+			raw := attr.ValueString()
+			if tmp := redactString(rs, attr.Key, raw); tmp != raw {
+				attr = Attr(attr.Key, tmp)
+			}
+		}
+		out = append(out, attr)
+	}
+	return out
+}
+
+// redactedStringer the metadata that apply the redactors in String() by themselves
+type redactedStringer interface {
+	redactedString(rs []Redactor) string
+}
+
+// redactedString the String() of the metadata with the redactors of its module applied.
+// the metadata implementing zapcore.ObjectMarshaler are printed as JSON objects through the redactors,
+// the others are redacted as a whole at the path 'value', the same as NewMD.
+func redactedString(md Metadata) string {
+	rs := getRedactors(md.Module())
+	if r, ok := md.(redactedStringer); ok {
+		return r.redactedString(rs)
+	}
+	if len(rs) == 0 {
+		return md.String()
+	}
+	if m, ok := md.(zapcore.ObjectMarshaler); ok {
+		if out, ok := objectJSON(&redactedMD{md: m, redactors: rs}); ok {
+			return out
+		}
+	}
+	return redactString(rs, mdValueKey, md.String())
+}
+
+// objectJSON encode the object the way it is printed as a JSON object
+func objectJSON(m zapcore.ObjectMarshaler) (string, bool) {
+	enc := zapcore.NewMapObjectEncoder()
+	if err := m.MarshalLogObject(enc); err != nil {
+		return "", false
+	}
+	out, err := json.MarshalToString(enc.Fields)
+	return out, err == nil
+}
+
+// =======================================
+// ------------ Chain Entry --------------
+// =======================================
+
+// chainEntry a metadata in the form it is printed, which is what the limits of the chain measure.
+// it is printed as object or reflected if either is set, or as text.
+type chainEntry struct {
+	object    zapcore.ObjectMarshaler
+	reflected any
+	// text the JSON of object or reflected, or the string printed
+	text string
+	// quoted the length of text as a JSON string, if it is printed as a string
+	quoted int
+}
+
+// encodeEntry encode the metadata with the redactors of its module applied:
+// zapcore.ObjectMarshaler as a JSON object, the others as the JSON of themselves, or as strings if there are redactors for them.
+// an entry whose encoded form is longer than maxEntryBytes becomes the form truncated to maxEntryBytes.
+func encodeEntry(md Metadata, maxEntryBytes int) chainEntry {
+	rs := getRedactors(md.Module())
+	var entry chainEntry
+	switch {
+	case isObjectMarshaler(md):
+		obj := zapcore.ObjectMarshaler(&redactedMD{md: md.(zapcore.ObjectMarshaler), redactors: rs})
+		if len(rs) == 0 {
+			obj = md.(zapcore.ObjectMarshaler)
+		}
+		if out, ok := objectJSON(obj); ok {
+			entry = chainEntry{object: obj, text: out}
+		} else {
+			entry = chainEntry{text: redactedString(md)}
+		}
+	case len(rs) > 0:
+		entry = chainEntry{text: redactedString(md)}
+	default:
+		if out, err := json.MarshalToString(md); err == nil {
+			entry = chainEntry{reflected: md, text: out}
+		} else {
+			entry = chainEntry{text: md.String()}
+		}
+	}
+	if maxEntryBytes > 0 && len(entry.text) > maxEntryBytes {
+		entry = chainEntry{text: truncate(entry.text, maxEntryBytes)}
+	}
+	if entry.object == nil && entry.reflected == nil {
+		if out, err := json.MarshalToString(entry.text); err == nil {
+			entry.quoted = len(out)
+		}
+	}
+	return entry
+}
+
+func isObjectMarshaler(md Metadata) bool {
+	_, ok := md.(zapcore.ObjectMarshaler)
+	return ok
+}
+
+// size the length of the entry as it is printed in JSON
+func (e chainEntry) size() int {
+	if e.quoted > 0 {
+		return e.quoted
+	}
+	return len(e.text)
+}
+
+func (e chainEntry) appendTo(enc zapcore.ArrayEncoder) {
+	switch {
+	case e.object != nil:
+		_ = enc.AppendObject(e.object)
+	case e.reflected != nil:
+		_ = enc.AppendReflected(e.reflected)
+	default:
+		enc.AppendString(e.text)
+	}
+}
+
+// RedactKeys replace the whole value of the fields whose key matches any of the regular expressions.
+// a pattern is matched against both the full path and the last key of the path.
+func RedactKeys(patterns ...string) (Redactor, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, berror.NewInvalidArgument(err, "invalid redaction key pattern: "+p)
+		}
+		res = append(res, re)
+	}
+	return RedactorFunc(func(path string, value string) string {
+		key := lastKey(path)
+		for _, re := range res {
+			if re.MatchString(path) || re.MatchString(key) {
+				return redactedValue
+			}
+		}
+		return value
+	}), nil
+}
+
+// RedactJSONPaths replace the values at the JSON paths, if the field is a JSON document.
+// field is matched against both the full path and the last key of the path.
+// a JSON path is dot-separated, '*' matches any key or array element, such as 'user.password' or 'items.*.token'.
+func RedactJSONPaths(field string, paths ...string) Redactor {
+	segments := make([][]string, 0, len(paths))
+	for _, p := range paths {
+		segments = append(segments, strings.Split(strings.TrimPrefix(p, "$."), "."))
+	}
+	return RedactorFunc(func(path string, value string) string {
+		if path != field && lastKey(path) != field {
+			return value
+		}
+		var doc any
+		if err := json.UnmarshalFromString(value, &doc); err != nil {
+			return value
+		}
+		changed := false
+		for _, s := range segments {
+			doc = redactJSON(doc, s, &changed)
+		}
+		if !changed {
+			return value
+		}
+		out, err := json.MarshalToString(doc)
+		if err != nil {
+			return value
+		}
+		return out
+	})
+}
+
+func redactJSON(node any, path []string, changed *bool) any {
+	if len(path) == 0 {
+		*changed = true
+		return redactedValue
+	}
+	switch tmp := node.(type) {
+	case map[string]any:
+		for k, v := range tmp {
+			if path[0] == "*" || path[0] == k {
+				tmp[k] = redactJSON(v, path[1:], changed)
+			}
+		}
+	case []any:
+		idx, err := strconv.Atoi(path[0])
+		for i, v := range tmp {
+			if path[0] == "*" || (err == nil && idx == i) {
+				tmp[i] = redactJSON(v, path[1:], changed)
+			}
+		}
+	}
+	return node
+}
+
+func lastKey(path string) string {
+	if idx := strings.LastIndexByte(path, '.'); idx >= 0 {
+		return path[idx+1:]
+	}
+	return path
+}
+
+// =======================================
+// ------ Redacted Encoder Wrapper -------
+// =======================================
+
+// redactedMD print the metadata through redactedEncoder
+type redactedMD struct {
+	md        zapcore.ObjectMarshaler
+	redactors []Redactor
+}
+
+func (r *redactedMD) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return r.md.MarshalLogObject(&redactedEncoder{ObjectEncoder: enc, redactors: r.redactors})
+}
+
+func joinPath(prefix, key string) string {
+	if len(prefix) == 0 {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// redactedEncoder redact the string values on the way to the real encoder
+type redactedEncoder struct {
+	zapcore.ObjectEncoder
+	redactors []Redactor
+	prefix    string
+}
+
+func (e *redactedEncoder) AddString(key, value string) {
+	e.ObjectEncoder.AddString(key, redactString(e.redactors, joinPath(e.prefix, key), value))
+}
+
+func (e *redactedEncoder) AddByteString(key string, value []byte) {
+	e.ObjectEncoder.AddString(key, redactString(e.redactors, joinPath(e.prefix, key), string(value)))
+}
+
+func (e *redactedEncoder) AddReflected(key string, value any) error {
+	path := joinPath(e.prefix, key)
+	raw, err := json.MarshalToString(value)
+	if err != nil {
+		return e.ObjectEncoder.AddReflected(key, value)
+	}
+	if out := redactString(e.redactors, path, raw); out != raw {
+		e.ObjectEncoder.AddString(key, out)
+		return nil
+	}
+	return e.ObjectEncoder.AddReflected(key, value)
+}
+
+func (e *redactedEncoder) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	path := joinPath(e.prefix, key)
+	return e.ObjectEncoder.AddObject(key, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		return marshaler.MarshalLogObject(&redactedEncoder{ObjectEncoder: enc, redactors: e.redactors, prefix: path})
+	}))
+}
+
+func (e *redactedEncoder) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	path := joinPath(e.prefix, key)
+	return e.ObjectEncoder.AddArray(key, zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		return marshaler.MarshalLogArray(&redactedArrayEncoder{ArrayEncoder: enc, redactors: e.redactors, path: path})
+	}))
+}
+
+func (e *redactedEncoder) OpenNamespace(key string) {
+	e.ObjectEncoder.OpenNamespace(key)
+	e.prefix = joinPath(e.prefix, key)
+}
+
+// redactedArrayEncoder the elements share the path of the array
+type redactedArrayEncoder struct {
+	zapcore.ArrayEncoder
+	redactors []Redactor
+	path      string
+}
+
+func (e *redactedArrayEncoder) AppendString(value string) {
+	e.ArrayEncoder.AppendString(redactString(e.redactors, e.path, value))
+}
+
+func (e *redactedArrayEncoder) AppendByteString(value []byte) {
+	e.ArrayEncoder.AppendString(redactString(e.redactors, e.path, string(value)))
+}
+
+func (e *redactedArrayEncoder) AppendReflected(value any) error {
+	raw, err := json.MarshalToString(value)
+	if err != nil {
+		return e.ArrayEncoder.AppendReflected(value)
+	}
+	if out := redactString(e.redactors, e.path, raw); out != raw {
+		e.ArrayEncoder.AppendString(out)
+		return nil
+	}
+	return e.ArrayEncoder.AppendReflected(value)
+}
+
+func (e *redactedArrayEncoder) AppendObject(marshaler zapcore.ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		return marshaler.MarshalLogObject(&redactedEncoder{ObjectEncoder: enc, redactors: e.redactors, prefix: e.path})
+	}))
+}
+
+func (e *redactedArrayEncoder) AppendArray(marshaler zapcore.ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		return marshaler.MarshalLogArray(&redactedArrayEncoder{ArrayEncoder: enc, redactors: e.redactors, path: e.path})
+	}))
+}
@@ -0,0 +1,143 @@
+package btrace_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lamber92/go-brick/bcontext"
+	"github.com/lamber92/go-brick/btrace"
+	"github.com/lamber92/go-brick/internal/json"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func marshalChain(chain btrace.Chain) []any {
+	enc := zapcore.NewMapObjectEncoder()
+	_ = enc.AddArray("trace", chain.Get())
+	return enc.Fields["trace"].([]any)
+}
+
+func TestChainLimits(t *testing.T) {
+	chain := btrace.NewChainWithLimits(btrace.ChainLimits{MaxEntries: 2})
+	chain.Append(btrace.NewMD(testMod1, "1"), btrace.NewMD(testMod1, "2"), btrace.NewMD(testMod1, "3"))
+	assert.Equal(t, 2, len(chain.Get()))
+	assert.Equal(t, 1, chain.Dropped())
+	assert.Contains(t, chain.String(), "(dropped: 1)")
+	chain.Clear()
+	assert.Equal(t, 0, chain.Dropped())
+
+	// the size of an entry is the length of its encoded form: {"module":"test_mod 1","value":"xx"} -> 36 bytes
+	chain = btrace.NewChainWithLimits(btrace.ChainLimits{MaxBytes: 80})
+	chain.Append(btrace.NewMD(testMod1, "xx"), btrace.NewMD(testMod1, "xx"), btrace.NewMD(testMod1, "xx"))
+	assert.Equal(t, 2, len(chain.Get()))
+	assert.Equal(t, 1, chain.Dropped())
+}
+
+func TestChainEntryTruncation(t *testing.T) {
+	orig := btrace.GetChainLimits()
+	btrace.ReplaceChainLimits(btrace.ChainLimits{MaxEntryBytes: 40})
+	defer btrace.ReplaceChainLimits(orig)
+
+	// the whole encoded entry is truncated, and printed as a string
+	chain := btrace.NewChain()
+	chain.Append(btrace.NewMD(testMod1, "0123456789"))
+	out := marshalChain(chain)
+	assert.Equal(t, `{"module":"test_mod 1","value":"01234567...(truncated 4 bytes)`, out[0])
+	// multi-bytes characters are never split
+	chain.Append(btrace.NewMD(testMod1, strings.Repeat("砖", 4)))
+	out = marshalChain(chain)
+	assert.Equal(t, `{"module":"test_mod 1","value":"砖砖...(truncated 8 bytes)`, out[1])
+	// the formatted output is truncated the same
+	assert.Equal(t, "[1]"+out[0].(string)+" --> [2]"+out[1].(string), chain.String())
+	// short entries are untouched
+	chain.Clear()
+	chain.Append(btrace.NewMD(testMod1, "0"))
+	assert.Equal(t, map[string]any{"module": "test_mod 1", "value": "0"}, marshalChain(chain)[0])
+}
+
+func TestChainMaxBytes(t *testing.T) {
+	orig := btrace.GetChainLimits()
+	limits := btrace.ChainLimits{MaxBytes: 512, MaxEntryBytes: 128}
+	btrace.ReplaceChainLimits(limits)
+	defer btrace.ReplaceChainLimits(orig)
+
+	// every field of the span is long, but the entry is limited as a whole
+	ctx := bcontext.New()
+	for i := 0; i < 20; i++ {
+		_, span := btrace.StartSpan(ctx, "max_bytes",
+			btrace.Attr("a", strings.Repeat("a", 100)),
+			btrace.Attr("b", strings.Repeat("b", 100)),
+			btrace.Attr("c", strings.Repeat("c", 100)),
+		)
+		span.End()
+		btrace.AppendMDIntoCtx(ctx, btrace.NewMD(testMod1, strings.Repeat("x", 20)))
+	}
+	chain, _ := btrace.GetMDFromCtx(ctx)
+	assert.Greater(t, chain.Dropped(), 0)
+
+	size := 0
+	for _, v := range marshalChain(chain) {
+		raw, err := json.Marshal(v)
+		assert.Nil(t, err)
+		size += len(raw)
+	}
+	assert.LessOrEqual(t, size, limits.MaxBytes)
+	assert.LessOrEqual(t, len(chain.String()), limits.MaxBytes+len(" (dropped: 99)")+5*len(chain.Get()))
+}
+
+func TestRedactor(t *testing.T) {
+	const mod btrace.Module = "redact_test"
+	keys, err := btrace.RedactKeys(`(?i)^password$`)
+	assert.Equal(t, nil, err)
+	btrace.RegisterRedactor(mod, keys, btrace.RedactJSONPaths("body", "user.token", "items.*.card"))
+
+	_, err = btrace.RedactKeys(`(`)
+	assert.NotEqual(t, nil, err)
+
+	ctx := bcontext.New()
+	_, span := btrace.StartSpan(ctx, string(mod),
+		btrace.Attr("Password", "123456"),
+		btrace.Attr("body", `{"user":{"name":"lamber","token":"abc"},"items":[{"card":"1"},{"card":"2"}]}`),
+		btrace.Attr("other", "plain"),
+	)
+	span.End()
+	chain, _ := btrace.GetMDFromCtx(ctx)
+	out := marshalChain(chain)[0].(map[string]any)
+	assert.Equal(t, "******", out["Password"])
+	assert.Equal(t, `{"items":[{"card":"******"},{"card":"******"}],"user":{"name":"lamber","token":"******"}}`, out["body"])
+	assert.Equal(t, "plain", out["other"])
+	// the same for the formatted output
+	str := chain.String()
+	assert.NotContains(t, str, "123456")
+	assert.NotContains(t, str, `"abc"`)
+	assert.Contains(t, str, `"Password":"******"`)
+	// the exported attributes
+	for _, attr := range btrace.RedactAttributes(mod, span.Attributes()) {
+		assert.NotContains(t, attr.ValueString(), "123456", attr.Key)
+		assert.NotContains(t, attr.ValueString(), `"abc"`, attr.Key)
+	}
+	// the metadata that are not zapcore.ObjectMarshaler are redacted at the path 'value'
+	values, err := btrace.RedactKeys(`^value$`)
+	assert.Equal(t, nil, err)
+	btrace.RegisterRedactor("redact_plain_test", values)
+	plain := btrace.NewChain()
+	plain.Append(plainMD("password=123456"), btrace.NewMD("redact_plain_test", "password=123456"))
+	assert.NotContains(t, plain.String(), "123456")
+	assert.Equal(t, []any{"******", map[string]any{"module": "redact_plain_test", "value": "******"}}, marshalChain(plain))
+
+	// other modules are untouched
+	chain.Clear()
+	chain.Append(btrace.NewMD(testMod1, "password"))
+	assert.Equal(t, "password", marshalChain(chain)[0].(map[string]any)["value"])
+}
+
+// plainMD a metadata that is not zapcore.ObjectMarshaler
+type plainMD string
+
+func (p plainMD) Module() btrace.Module {
+	return "redact_plain_test"
+}
+
+func (p plainMD) String() string {
+	return string(p)
+}